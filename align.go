@@ -0,0 +1,57 @@
+// Alignment of strokes to one side of closed subpaths
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+// StrokeAlign determines where the stroke of a closed subpath lies relative
+// to the subpath
+type StrokeAlign uint8
+
+// StrokeAlign constants, which correspond to the stroke-alignment values of
+// design tools and the CSS Fill and Stroke draft
+const (
+	// AlignCenter centers the stroke on the path
+	AlignCenter StrokeAlign = iota
+	// AlignInside places the stroke inside of the region filled by the
+	// closed subpath, with the path as its outer edge
+	AlignInside
+	// AlignOutside places the stroke outside of the region filled by the
+	// closed subpath, with the path as its inner edge
+	AlignOutside
+)
+
+// SetAlignment sets where the stroke of each closed subpath is drawn, until
+// the next call to SetStroke, which centers it again. Open
+// subpaths are always stroked along their center. An inside or outside stroke
+// is the stroke of twice the width, with its joins, caps and dashes, clipped
+// to the inside or the outside of the subpath by the nonzero winding rule.
+// The clip needs the whole subpath, so each subpath is held until it is
+// stopped, and nothing is drawn for it before then; stop every subpath
+// before calling Draw.
+func (r *Stroker) SetAlignment(a StrokeAlign) {
+	r.align = a
+	r.w.buf.Clear()
+}
+
+// Alignment returns the stroke alignment set by SetAlignment
+func (r *Stroker) Alignment() StrokeAlign {
+	return r.align
+}
+
+// strokeAligned strokes the collected closed subpath at twice the width by
+// replaying it to q, captures the outline, and sends the part of it on the
+// aligned side of the subpath to the sink.
+func (r *Stroker) strokeAligned(q Adder) {
+	u, mLimit := r.u, r.mLimit
+	r.u, r.mLimit = 2*u, 2*mLimit
+	r.w.wide = true
+	o := r.outline(q, r.w.buf)
+	r.w.wide = false
+	r.u, r.mLimit = u, mLimit
+
+	op := IntersectOp
+	if r.align == AlignOutside {
+		op = DifferenceOp
+	}
+	r.addOutline(Boolean(op, o, r.w.buf, true))
+}
@@ -0,0 +1,353 @@
+// Bezier curve segment types
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"math"
+	"sort"
+
+	"golang.org/x/image/math/fixed"
+)
+
+type (
+	// QuadBez is a quadratic Bezier curve in floating point pixel coordinates.
+	// P0 and P2 are the end points and P1 is the control point.
+	QuadBez struct {
+		P0, P1, P2 Point
+	}
+	// CubicBez is a cubic Bezier curve in floating point pixel coordinates.
+	// P0 and P3 are the end points and P1 and P2 are the control points.
+	CubicBez struct {
+		P0, P1, P2, P3 Point
+	}
+	// QuadBezFixed is a quadratic Bezier curve in fixed point coordinates,
+	// as sent to an Adder. Its methods work in floating point and round
+	// the results.
+	QuadBezFixed struct {
+		P0, P1, P2 fixed.Point26_6
+	}
+	// CubicBezFixed is a cubic Bezier curve in fixed point coordinates,
+	// as sent to an Adder. Its methods work in floating point and round
+	// the results.
+	CubicBezFixed struct {
+		P0, P1, P2, P3 fixed.Point26_6
+	}
+)
+
+// quadRoots returns the real roots of a*t*t + b*t + c = 0 that are strictly
+// between 0 and 1
+func quadRoots(a, b, c float64) (ts []float64) {
+	add := func(t float64) {
+		if t > 0 && t < 1 {
+			ts = append(ts, t)
+		}
+	}
+	if math.Abs(a) < 1e-12 {
+		if b != 0 {
+			add(-c / b)
+		}
+		return
+	}
+	disc := b*b - 4*a*c
+	if disc < 0 {
+		return
+	}
+	// Avoid cancellation by computing the larger root first
+	q := -(b + math.Copysign(math.Sqrt(disc), b)) / 2
+	add(q / a)
+	if q != 0 {
+		add(c / q)
+	}
+	return
+}
+
+// curvature returns the signed curvature given the first and second
+// derivatives of a curve, or 0 if the first derivative is zero. It is positive
+// where the curve turns clockwise on the screen.
+func curvature(d1, d2 Point) float64 {
+	l := d1.Len()
+	if l == 0 {
+		return 0
+	}
+	return d1.Cross(d2) / (l * l * l)
+}
+
+// boundsOf returns the bounding box of the end points and the points at ts
+func boundsOf(eval func(t float64) Point, ts []float64) (min, max Point) {
+	min, max = eval(0), eval(0)
+	for _, t := range append(ts, 1) {
+		p := eval(t)
+		min = Point{X: math.Min(min.X, p.X), Y: math.Min(min.Y, p.Y)}
+		max = Point{X: math.Max(max.X, p.X), Y: math.Max(max.Y, p.Y)}
+	}
+	return
+}
+
+// fixedBounds returns the smallest fixed point rectangle containing the box
+// from min to max
+func fixedBounds(min, max Point) fixed.Rectangle26_6 {
+	return fixed.Rectangle26_6{
+		Min: fixed.Point26_6{X: fixed.Int26_6(math.Floor(min.X * 64)), Y: fixed.Int26_6(math.Floor(min.Y * 64))},
+		Max: fixed.Point26_6{X: fixed.Int26_6(math.Ceil(max.X * 64)), Y: fixed.Int26_6(math.Ceil(max.Y * 64))}}
+}
+
+// Eval returns the point on the curve at parameter t
+func (q QuadBez) Eval(t float64) Point {
+	mt := 1 - t
+	return q.P0.Mul(mt * mt).Add(q.P1.Mul(2 * mt * t)).Add(q.P2.Mul(t * t))
+}
+
+// Deriv returns the derivative of the curve with respect to t
+func (q QuadBez) Deriv(t float64) Point {
+	return q.P1.Sub(q.P0).Mul(2 * (1 - t)).Add(q.P2.Sub(q.P1).Mul(2 * t))
+}
+
+// Deriv2 returns the second derivative of the curve, which is constant
+func (q QuadBez) Deriv2() Point {
+	return q.P0.Sub(q.P1.Mul(2)).Add(q.P2).Mul(2)
+}
+
+// Split divides the curve at t using de Casteljau's algorithm
+func (q QuadBez) Split(t float64) (a, b QuadBez) {
+	p01, p12 := lerpP(t, q.P0, q.P1), lerpP(t, q.P1, q.P2)
+	m := lerpP(t, p01, p12)
+	return QuadBez{q.P0, p01, m}, QuadBez{m, p12, q.P2}
+}
+
+// Subsegment returns the part of the curve between t0 and t1, which may be
+// in either order
+func (q QuadBez) Subsegment(t0, t1 float64) QuadBez {
+	// Blossoming gives the control points of the part directly
+	b := func(u, v float64) Point {
+		return lerpP(v, lerpP(u, q.P0, q.P1), lerpP(u, q.P1, q.P2))
+	}
+	return QuadBez{q.Eval(t0), b(t0, t1), q.Eval(t1)}
+}
+
+// Extrema returns the sorted parameters strictly between 0 and 1 at which
+// the curve turns in x or y
+func (q QuadBez) Extrema() []float64 {
+	a, b := q.P1.Sub(q.P0), q.P2.Sub(q.P1)
+	ts := append(quadRoots(0, b.X-a.X, a.X), quadRoots(0, b.Y-a.Y, a.Y)...)
+	sort.Float64s(ts)
+	return ts
+}
+
+// Bounds returns the corners of the smallest box containing the curve
+func (q QuadBez) Bounds() (min, max Point) {
+	return boundsOf(q.Eval, q.Extrema())
+}
+
+// Curvature returns the signed curvature of the curve at t, which is the
+// reciprocal of the radius of the osculating circle. It is positive where the
+// curve turns clockwise on the screen, and 0 where the derivative vanishes.
+func (q QuadBez) Curvature(t float64) float64 {
+	return curvature(q.Deriv(t), q.Deriv2())
+}
+
+// Elevate returns the cubic curve that traces the same path
+func (q QuadBez) Elevate() CubicBez {
+	return CubicBez{q.P0, lerpP(2.0/3, q.P0, q.P1), lerpP(2.0/3, q.P2, q.P1), q.P2}
+}
+
+// Eval returns the point on the curve at parameter t
+func (c CubicBez) Eval(t float64) Point {
+	mt := 1 - t
+	return c.P0.Mul(mt * mt * mt).Add(c.P1.Mul(3 * mt * mt * t)).
+		Add(c.P2.Mul(3 * mt * t * t)).Add(c.P3.Mul(t * t * t))
+}
+
+// Deriv returns the derivative of the curve with respect to t
+func (c CubicBez) Deriv(t float64) Point {
+	mt := 1 - t
+	return c.P1.Sub(c.P0).Mul(3 * mt * mt).Add(c.P2.Sub(c.P1).Mul(6 * mt * t)).
+		Add(c.P3.Sub(c.P2).Mul(3 * t * t))
+}
+
+// Deriv2 returns the second derivative of the curve with respect to t
+func (c CubicBez) Deriv2(t float64) Point {
+	a := c.P0.Sub(c.P1.Mul(2)).Add(c.P2)
+	b := c.P1.Sub(c.P2.Mul(2)).Add(c.P3)
+	return lerpP(t, a, b).Mul(6)
+}
+
+// Split divides the curve at t using de Casteljau's algorithm
+func (c CubicBez) Split(t float64) (a, b CubicBez) {
+	p01, p12, p23 := lerpP(t, c.P0, c.P1), lerpP(t, c.P1, c.P2), lerpP(t, c.P2, c.P3)
+	p012, p123 := lerpP(t, p01, p12), lerpP(t, p12, p23)
+	m := lerpP(t, p012, p123)
+	return CubicBez{c.P0, p01, p012, m}, CubicBez{m, p123, p23, c.P3}
+}
+
+// Subsegment returns the part of the curve between t0 and t1, which may be
+// in either order
+func (c CubicBez) Subsegment(t0, t1 float64) CubicBez {
+	// Blossoming gives the control points of the part directly
+	b := func(u, v, w float64) Point {
+		p01, p12, p23 := lerpP(u, c.P0, c.P1), lerpP(u, c.P1, c.P2), lerpP(u, c.P2, c.P3)
+		return lerpP(w, lerpP(v, p01, p12), lerpP(v, p12, p23))
+	}
+	return CubicBez{c.Eval(t0), b(t0, t0, t1), b(t0, t1, t1), c.Eval(t1)}
+}
+
+// Extrema returns the sorted parameters strictly between 0 and 1 at which
+// the curve turns in x or y
+func (c CubicBez) Extrema() []float64 {
+	a, b, d := c.P1.Sub(c.P0), c.P2.Sub(c.P1), c.P3.Sub(c.P2)
+	// The derivative is 3*((1-t)^2 a + 2t(1-t) b + t^2 d)
+	ca, cb := a.Sub(b.Mul(2)).Add(d), b.Sub(a).Mul(2)
+	ts := append(quadRoots(ca.X, cb.X, a.X), quadRoots(ca.Y, cb.Y, a.Y)...)
+	sort.Float64s(ts)
+	return ts
+}
+
+// Bounds returns the corners of the smallest box containing the curve
+func (c CubicBez) Bounds() (min, max Point) {
+	return boundsOf(c.Eval, c.Extrema())
+}
+
+// Curvature returns the signed curvature of the curve at t, which is the
+// reciprocal of the radius of the osculating circle. It is positive where the
+// curve turns clockwise on the screen, and 0 where the derivative vanishes.
+func (c CubicBez) Curvature(t float64) float64 {
+	return curvature(c.Deriv(t), c.Deriv2(t))
+}
+
+// ToQuads approximates the curve with quadratic curves that are within tol
+// of it. The cubic is divided into equal parameter ranges, and each part is
+// replaced by the quadratic that matches its end points and midpoint
+// tangent, which differs from the part by at most sqrt(3)/36 times the
+// length of its third difference.
+func (c CubicBez) ToQuads(tol float64) []QuadBez {
+	d3 := c.P3.Sub(c.P2.Mul(3)).Add(c.P1.Mul(3)).Sub(c.P0).Len()
+	n := 1
+	if tol > 0 {
+		n = int(math.Ceil(math.Cbrt(math.Sqrt(3) / 36 * d3 / tol)))
+		if n < 1 {
+			n = 1
+		}
+	}
+	quads := make([]QuadBez, n)
+	for i := range quads {
+		p := c.Subsegment(float64(i)/float64(n), float64(i+1)/float64(n))
+		ctrl := p.P1.Add(p.P2).Mul(3).Sub(p.P0).Sub(p.P3).Mul(0.25)
+		quads[i] = QuadBez{p.P0, ctrl, p.P3}
+	}
+	quads[n-1].P2 = c.P3
+	return quads
+}
+
+// Float returns the curve in floating point coordinates
+func (q QuadBezFixed) Float() QuadBez {
+	return QuadBez{ToPoint(q.P0), ToPoint(q.P1), ToPoint(q.P2)}
+}
+
+// Fixed returns the curve in fixed point coordinates, with each point rounded
+// to the nearest value
+func (q QuadBez) Fixed() QuadBezFixed {
+	return QuadBezFixed{q.P0.Fixed(), q.P1.Fixed(), q.P2.Fixed()}
+}
+
+// Eval returns the point on the curve at parameter t
+func (q QuadBezFixed) Eval(t float64) fixed.Point26_6 {
+	return q.Float().Eval(t).Fixed()
+}
+
+// Deriv returns the derivative of the curve with respect to t
+func (q QuadBezFixed) Deriv(t float64) fixed.Point26_6 {
+	return q.Float().Deriv(t).Fixed()
+}
+
+// Split divides the curve at t
+func (q QuadBezFixed) Split(t float64) (a, b QuadBezFixed) {
+	fa, fb := q.Float().Split(t)
+	return fa.Fixed(), fb.Fixed()
+}
+
+// Subsegment returns the part of the curve between t0 and t1
+func (q QuadBezFixed) Subsegment(t0, t1 float64) QuadBezFixed {
+	return q.Float().Subsegment(t0, t1).Fixed()
+}
+
+// Extrema returns the sorted parameters strictly between 0 and 1 at which
+// the curve turns in x or y
+func (q QuadBezFixed) Extrema() []float64 {
+	return q.Float().Extrema()
+}
+
+// Bounds returns the smallest rectangle containing the curve
+func (q QuadBezFixed) Bounds() fixed.Rectangle26_6 {
+	return fixedBounds(q.Float().Bounds())
+}
+
+// Curvature returns the signed curvature of the curve at t, in reciprocal
+// pixels
+func (q QuadBezFixed) Curvature(t float64) float64 {
+	return q.Float().Curvature(t)
+}
+
+// Elevate returns the cubic curve that traces the same path
+func (q QuadBezFixed) Elevate() CubicBezFixed {
+	return q.Float().Elevate().Fixed()
+}
+
+// Float returns the curve in floating point coordinates
+func (c CubicBezFixed) Float() CubicBez {
+	return CubicBez{ToPoint(c.P0), ToPoint(c.P1), ToPoint(c.P2), ToPoint(c.P3)}
+}
+
+// Fixed returns the curve in fixed point coordinates, with each point rounded
+// to the nearest value
+func (c CubicBez) Fixed() CubicBezFixed {
+	return CubicBezFixed{c.P0.Fixed(), c.P1.Fixed(), c.P2.Fixed(), c.P3.Fixed()}
+}
+
+// Eval returns the point on the curve at parameter t
+func (c CubicBezFixed) Eval(t float64) fixed.Point26_6 {
+	return c.Float().Eval(t).Fixed()
+}
+
+// Deriv returns the derivative of the curve with respect to t
+func (c CubicBezFixed) Deriv(t float64) fixed.Point26_6 {
+	return c.Float().Deriv(t).Fixed()
+}
+
+// Split divides the curve at t
+func (c CubicBezFixed) Split(t float64) (a, b CubicBezFixed) {
+	fa, fb := c.Float().Split(t)
+	return fa.Fixed(), fb.Fixed()
+}
+
+// Subsegment returns the part of the curve between t0 and t1
+func (c CubicBezFixed) Subsegment(t0, t1 float64) CubicBezFixed {
+	return c.Float().Subsegment(t0, t1).Fixed()
+}
+
+// Extrema returns the sorted parameters strictly between 0 and 1 at which
+// the curve turns in x or y
+func (c CubicBezFixed) Extrema() []float64 {
+	return c.Float().Extrema()
+}
+
+// Bounds returns the smallest rectangle containing the curve
+func (c CubicBezFixed) Bounds() fixed.Rectangle26_6 {
+	return fixedBounds(c.Float().Bounds())
+}
+
+// Curvature returns the signed curvature of the curve at t, in reciprocal
+// pixels
+func (c CubicBezFixed) Curvature(t float64) float64 {
+	return c.Float().Curvature(t)
+}
+
+// ToQuads approximates the curve with quadratic curves that are within tol
+// pixels of it, before rounding
+func (c CubicBezFixed) ToQuads(tol float64) []QuadBezFixed {
+	fq := c.Float().ToQuads(tol)
+	quads := make([]QuadBezFixed, len(fq))
+	for i, q := range fq {
+		quads[i] = q.Fixed()
+	}
+	return quads
+}
@@ -9,7 +9,6 @@ import (
 	"time"
 
 	. "github.com/srwiley/rasterx"
-	//"golang.org/x/image/math/fixed"
 )
 
 // Copied from golang.org/x/image/vector
@@ -205,3 +204,133 @@ func TestBezierQuad(t *testing.T) {
 	}
 
 }
+
+// bezierDist returns the largest distance from points along the curve to the
+// polyline pts, which must start at the start of the curve.
+func bezierDist(pts []Point, eval func(t float64) Point) (max float64) {
+	for i := 0; i <= 200; i++ {
+		c := eval(float64(i) / 200)
+		d := math.Inf(1)
+		for j := 1; j < len(pts); j++ {
+			a, b := pts[j-1], pts[j]
+			ab := b.Sub(a)
+			t := 0.0
+			if l := ab.Dot(ab); l > 0 {
+				t = math.Max(0, math.Min(1, c.Sub(a).Dot(ab)/l))
+			}
+			if dd := c.Sub(a.Add(ab.Mul(t))).Len(); dd < d {
+				d = dd
+			}
+		}
+		if d > max {
+			max = d
+		}
+	}
+	return
+}
+
+func TestBezierTol(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, tol := range []float64{0.05, 0.5, 3} {
+		for i := 0; i < 50; i++ {
+			var c [8]float64
+			for j := range c {
+				c[j] = float64(rnd.Intn(400))
+			}
+			pts := []Point{{c[0], c[1]}}
+			CubeToTol(c[0], c[1], c[2], c[3], c[4], c[5], c[6], c[7], tol, func(ex, ey float64) {
+				pts = append(pts, Point{ex, ey})
+			})
+			d := bezierDist(pts, func(s float64) Point {
+				u := 1 - s
+				return Point{u*u*u*c[0] + 3*u*u*s*c[2] + 3*u*s*s*c[4] + s*s*s*c[6],
+					u*u*u*c[1] + 3*u*u*s*c[3] + 3*u*s*s*c[5] + s*s*s*c[7]}
+			})
+			if d > tol {
+				t.Error("cubic flattening exceeds tolerance", tol, d, c)
+			}
+			pts = []Point{{c[0], c[1]}}
+			QuadToTol(c[0], c[1], c[2], c[3], c[4], c[5], tol, func(ex, ey float64) {
+				pts = append(pts, Point{ex, ey})
+			})
+			d = bezierDist(pts, func(s float64) Point {
+				u := 1 - s
+				return Point{u*u*c[0] + 2*u*s*c[2] + s*s*c[4], u*u*c[1] + 2*u*s*c[3] + s*s*c[5]}
+			})
+			if d > tol {
+				t.Error("quad flattening exceeds tolerance", tol, d, c)
+			}
+		}
+	}
+}
+
+func TestBezierTypes(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	rp := func() Point { return Point{X: float64(rnd.Intn(200)), Y: float64(rnd.Intn(200))} }
+	near := func(p, q Point, eps float64) bool { return p.Sub(q).Len() <= eps }
+	for i := 0; i < 50; i++ {
+		c := CubicBez{rp(), rp(), rp(), rp()}
+		q := QuadBez{rp(), rp(), rp()}
+		a, b := c.Split(0.3)
+		if !near(a.Eval(0.5), c.Eval(0.15), 1e-9) || !near(b.Eval(0.5), c.Eval(0.65), 1e-9) {
+			t.Error("cubic split wrong", c)
+		}
+		qa, qb := q.Split(0.6)
+		if !near(qa.Eval(0.5), q.Eval(0.3), 1e-9) || !near(qb.Eval(0.5), q.Eval(0.8), 1e-9) {
+			t.Error("quad split wrong", q)
+		}
+		cs, qs := c.Subsegment(0.2, 0.7), q.Subsegment(0.7, 0.2)
+		if !near(cs.Eval(0.4), c.Eval(0.4), 1e-9) || !near(qs.Eval(0.4), q.Eval(0.5), 1e-9) {
+			t.Error("subsegment wrong", c, q)
+		}
+		// The bounds contain the curve and are touched by it
+		min, max := c.Bounds()
+		lo, hi := c.Eval(0), c.Eval(0)
+		for j := 0; j <= 1000; j++ {
+			p := c.Eval(float64(j) / 1000)
+			lo = Point{X: math.Min(lo.X, p.X), Y: math.Min(lo.Y, p.Y)}
+			hi = Point{X: math.Max(hi.X, p.X), Y: math.Max(hi.Y, p.Y)}
+		}
+		if lo.X < min.X-1e-9 || lo.Y < min.Y-1e-9 || hi.X > max.X+1e-9 || hi.Y > max.Y+1e-9 ||
+			!near(lo, min, 0.01) || !near(hi, max, 0.01) {
+			t.Error("cubic bounds wrong", min, max, lo, hi)
+		}
+		for _, et := range c.Extrema() {
+			if d := c.Deriv(et); math.Min(math.Abs(d.X), math.Abs(d.Y)) > 1e-6 {
+				t.Error("derivative not zero at extremum", et, d)
+			}
+		}
+		e := q.Elevate()
+		if !near(e.Eval(0.37), q.Eval(0.37), 1e-9) {
+			t.Error("elevated quad differs", q, e)
+		}
+		quads := c.ToQuads(0.1)
+		for j, qd := range quads {
+			t0 := float64(j) / float64(len(quads))
+			t1 := float64(j+1) / float64(len(quads))
+			for k := 0; k <= 10; k++ {
+				u := float64(k) / 10
+				if !near(qd.Eval(u), c.Eval(t0+u*(t1-t0)), 0.1) {
+					t.Error("quad approximation exceeds tolerance", c, j, u)
+				}
+			}
+		}
+		if !near(quads[len(quads)-1].P2, c.P3, 0) {
+			t.Error("quads do not end at the cubic end")
+		}
+	}
+
+	// A quarter circle of radius 100 drawn clockwise on the screen
+	const k = 0.5522847498
+	arc := CubicBez{Point{100, 0}, Point{100, 100 * k}, Point{100 * k, 100}, Point{0, 100}}
+	if c := arc.Curvature(0.5); math.Abs(c-0.01) > 1e-4 {
+		t.Error("wrong curvature of circular arc", c)
+	}
+	fa := arc.Fixed()
+	if c := fa.Curvature(0); math.Abs(c-arc.Curvature(0)) > 1e-4 {
+		t.Error("fixed curvature differs", c)
+	}
+	if b := fa.Bounds(); b.Min != ToFixedP(0, 0) || b.Max != ToFixedP(100, 100) {
+		t.Error("wrong fixed bounds", b)
+	}
+}
@@ -0,0 +1,456 @@
+// Boolean operations on paths
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"math"
+	"sort"
+)
+
+// BooleanOp is the type of geometric combination performed by Boolean
+type BooleanOp uint8
+
+// BooleanOp constants
+const (
+	UnionOp BooleanOp = iota
+	IntersectOp
+	DifferenceOp
+	XorOp
+)
+
+const (
+	booleanTol = 0.01 // flattening tolerance of curves in boolean operations, in pixels
+	booleanEps = 1e-6 // distance below which points are considered coincident
+	sliverArea = 1e-3 // loops with a smaller area than this are dropped
+)
+
+type (
+	// bEdge is a straight edge of a flattened operand of a boolean operation.
+	// Each edge remembers the curve it was flattened from and its parameter
+	// range on that curve, so that curves can be restored in the result.
+	bEdge struct {
+		a, b   Point
+		src    int // index of the source curve
+		t0, t1 float64
+		opd    int // operand 0 or 1
+	}
+	// bSplit is a point at which an edge is cut
+	bSplit struct {
+		u float64 // parameter along the edge
+		p Point
+	}
+	// windingIndex buckets edges by horizontal band for fast winding number queries
+	windingIndex struct {
+		edges       []bEdge
+		minY, bandH float64
+		bands       [][]int
+	}
+)
+
+// Boolean returns the geometric union, intersection, difference (a minus b) or
+// exclusive or of the regions filled by the paths a and b, according to the
+// nonzero winding rule if useNonZeroWinding is true, or the even-odd rule
+// otherwise. Open subpaths are treated as closed, as they are when filled.
+// The result is a set of closed subpaths that do not cross or overlap each
+// other. The region is on the left of each subpath in the direction of travel,
+// so outer boundaries and holes wind in opposite directions and the result
+// fills the same with either winding rule. Curves of the operands are kept in
+// the result where they are not cut, and are split where they are.
+// A single path can be cleaned of self intersections and overlaps with
+// Boolean(UnionOp, p, nil, useNonZeroWinding).
+func Boolean(op BooleanOp, a, b Path, useNonZeroWinding bool) Path {
+	var curves []segment
+	var edges []bEdge
+	for opd, p := range [2]Path{a, b} {
+		for _, sp := range p.subpaths() {
+			if len(sp.segs) == 0 {
+				continue
+			}
+			last := sp.segs[len(sp.segs)-1].end()
+			if last != sp.start { // implicit close for filling
+				sp.segs = append(sp.segs, segment{P: [4]Point{last, sp.start}, deg: 1})
+			}
+			for _, s := range sp.segs {
+				src := len(curves)
+				curves = append(curves, s)
+				n := s.flattenCount(booleanTol)
+				prev := s.P[0]
+				for i := 1; i <= n; i++ {
+					t := float64(i) / float64(n)
+					next := s.eval(t)
+					if i == n {
+						next = s.end()
+					}
+					if next != prev {
+						edges = append(edges, bEdge{a: prev, b: next, src: src,
+							t0: float64(i-1) / float64(n), t1: t, opd: opd})
+					}
+					prev = next
+				}
+			}
+		}
+	}
+	// Keep the edges that separate the inside of the result from the outside,
+	// oriented with the inside on the left.
+	pieces := splitEdges(edges)
+	inside := [2]*windingIndex{}
+	for opd := range inside {
+		var es []bEdge
+		for _, e := range pieces {
+			if e.opd == opd {
+				es = append(es, e)
+			}
+		}
+		inside[opd] = newWindingIndex(es)
+	}
+	in := func(wa, wb int) bool {
+		var ina, inb bool
+		if useNonZeroWinding {
+			ina, inb = wa != 0, wb != 0
+		} else {
+			ina, inb = wa%2 != 0, wb%2 != 0
+		}
+		switch op {
+		case IntersectOp:
+			return ina && inb
+		case DifferenceOp:
+			return ina && !inb
+		case XorOp:
+			return ina != inb
+		}
+		return ina || inb
+	}
+	var kept []bEdge
+	seen := make(map[[2]Point]bool)
+	for _, e := range pieces {
+		if e.b.Sub(e.a).Len() < booleanEps {
+			continue
+		}
+		la, ra := inside[0].across(e)
+		lb, rb := inside[1].across(e)
+		left, right := in(la, lb), in(ra, rb)
+		if left == right {
+			continue
+		}
+		if right {
+			e.a, e.b, e.t0, e.t1 = e.b, e.a, e.t1, e.t0
+		}
+		key := [2]Point{e.a, e.b}
+		if seen[key] { // coincident edges of both operands
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, e)
+	}
+
+	var res Path
+	for _, loop := range chainEdges(kept) {
+		var area float64
+		for _, e := range loop {
+			area += e.a.Cross(e.b)
+		}
+		if math.Abs(area/2) < sliverArea {
+			continue
+		}
+		loopToSubpath(loop, curves).addTo(&res)
+	}
+	return res
+}
+
+// newWindingIndex returns a windingIndex for the edges
+func newWindingIndex(edges []bEdge) *windingIndex {
+	w := &windingIndex{edges: edges}
+	if len(edges) == 0 {
+		return w
+	}
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, e := range edges {
+		minY = math.Min(minY, math.Min(e.a.Y, e.b.Y))
+		maxY = math.Max(maxY, math.Max(e.a.Y, e.b.Y))
+	}
+	nb := int(math.Sqrt(float64(len(edges)))) + 1
+	w.minY, w.bandH = minY, (maxY-minY)/float64(nb)
+	if w.bandH <= 0 {
+		w.bandH = 1
+	}
+	w.bands = make([][]int, nb)
+	for i, e := range edges {
+		b0, b1 := w.band(math.Min(e.a.Y, e.b.Y)), w.band(math.Max(e.a.Y, e.b.Y))
+		for b := b0; b <= b1; b++ {
+			w.bands[b] = append(w.bands[b], i)
+		}
+	}
+	return w
+}
+
+// band returns the index of the band containing y, clamped to the bands
+func (w *windingIndex) band(y float64) int {
+	b := int((y - w.minY) / w.bandH)
+	if b < 0 {
+		return 0
+	}
+	if b >= len(w.bands) {
+		return len(w.bands) - 1
+	}
+	return b
+}
+
+// winding returns the winding number of the edges around q
+func (w *windingIndex) winding(q Point) (wn int) {
+	if len(w.bands) == 0 {
+		return
+	}
+	for _, i := range w.bands[w.band(q.Y)] {
+		e := w.edges[i]
+		side := e.b.Sub(e.a).Cross(q.Sub(e.a))
+		if e.a.Y <= q.Y {
+			if e.b.Y > q.Y && side > 0 {
+				wn++
+			}
+		} else if e.b.Y <= q.Y && side < 0 {
+			wn--
+		}
+	}
+	return
+}
+
+// across returns the winding numbers of the edges on the left and on the
+// right of the piece e, which is one of the pieces returned by splitEdges or
+// lies on none of them. The winding is found at the middle of e, leaving out
+// the pieces that lie on e, whose directions give the step from one side to
+// the other. Sampling beside e instead can cross a nearby piece where pieces
+// meet at shallow angles, and misjudge the side.
+func (w *windingIndex) across(e bEdge) (left, right int) {
+	if len(w.bands) == 0 {
+		return
+	}
+	q := lerpP(0.5, e.a, e.b)
+	var wn, step int
+	for _, i := range w.bands[w.band(q.Y)] {
+		f := w.edges[i]
+		switch {
+		case f.a == e.a && f.b == e.b:
+			step++
+			continue
+		case f.a == e.b && f.b == e.a:
+			step--
+			continue
+		}
+		side := f.b.Sub(f.a).Cross(q.Sub(f.a))
+		if f.a.Y <= q.Y {
+			if f.b.Y > q.Y && side > 0 {
+				wn++
+			}
+		} else if f.b.Y <= q.Y && side < 0 {
+			wn--
+		}
+	}
+	// wn is the winding on the side of e that the ray cast by winding leaves
+	// through, which is +x, or +y for a horizontal e
+	if d := e.b.Sub(e.a); d.Y > 0 || d.Y == 0 && d.X < 0 {
+		return wn + step, wn
+	}
+	return wn, wn - step
+}
+
+// splitEdges cuts the edges at all points where they cross or touch
+// each other, and returns the resulting pieces.
+func splitEdges(edges []bEdge) []bEdge {
+	splits := make([][]bSplit, len(edges))
+	order := make([]int, len(edges))
+	for i := range order {
+		order[i] = i
+	}
+	minX := func(e bEdge) float64 { return math.Min(e.a.X, e.b.X) }
+	sort.Slice(order, func(i, j int) bool { return minX(edges[order[i]]) < minX(edges[order[j]]) })
+	for oi, i := range order {
+		ei := edges[i]
+		maxX := math.Max(ei.a.X, ei.b.X) + booleanEps
+		iy0, iy1 := math.Min(ei.a.Y, ei.b.Y), math.Max(ei.a.Y, ei.b.Y)
+		for _, j := range order[oi+1:] {
+			ej := edges[j]
+			if minX(ej) > maxX {
+				break
+			}
+			if math.Max(ej.a.Y, ej.b.Y) < iy0-booleanEps || math.Min(ej.a.Y, ej.b.Y) > iy1+booleanEps {
+				continue
+			}
+			intersectEdges(ei, ej, &splits[i], &splits[j])
+		}
+	}
+
+	snap := newSnapper()
+	var out []bEdge
+	for i, e := range edges {
+		ss := splits[i]
+		sort.Slice(ss, func(a, b int) bool { return ss[a].u < ss[b].u })
+		prev, prevU := snap.point(e.a), 0.0
+		for k := 0; k <= len(ss); k++ {
+			next, nextU := e.b, 1.0
+			if k < len(ss) {
+				next, nextU = ss[k].p, ss[k].u
+			}
+			next = snap.point(next)
+			if next == prev {
+				continue
+			}
+			out = append(out, bEdge{a: prev, b: next, src: e.src, opd: e.opd,
+				t0: e.t0 + prevU*(e.t1-e.t0), t1: e.t0 + nextU*(e.t1-e.t0)})
+			prev, prevU = next, nextU
+		}
+	}
+	return out
+}
+
+// intersectEdges adds the points at which edges e and f meet to the split
+// lists si and sf. Points within booleanEps of an end point of an edge are
+// moved to that end point, so that both edges are cut at exactly the same place.
+func intersectEdges(e, f bEdge, se, sf *[]bSplit) {
+	d1, d2 := e.b.Sub(e.a), f.b.Sub(f.a)
+	l1, l2 := d1.Len(), d2.Len()
+	if l1 == 0 || l2 == 0 {
+		return
+	}
+	den := d1.Cross(d2)
+	w := f.a.Sub(e.a)
+	if math.Abs(den) <= booleanEps*l1*l2 { // parallel
+		if math.Abs(d1.Cross(w))/l1 > booleanEps {
+			return // not co-linear
+		}
+		// Each edge is cut where an end point of the other lies on it
+		for _, p := range [2]Point{f.a, f.b} {
+			if u := p.Sub(e.a).Dot(d1) / (l1 * l1); u*l1 > booleanEps && (1-u)*l1 > booleanEps {
+				*se = append(*se, bSplit{u: u, p: p})
+			}
+		}
+		for _, p := range [2]Point{e.a, e.b} {
+			if v := p.Sub(f.a).Dot(d2) / (l2 * l2); v*l2 > booleanEps && (1-v)*l2 > booleanEps {
+				*sf = append(*sf, bSplit{u: v, p: p})
+			}
+		}
+		return
+	}
+	u, v := w.Cross(d2)/den, w.Cross(d1)/den
+	du, dv := booleanEps/l1, booleanEps/l2
+	if u < -du || u > 1+du || v < -dv || v > 1+dv {
+		return
+	}
+	uIn, vIn := u > du && u < 1-du, v > dv && v < 1-dv
+	switch {
+	case uIn && vIn:
+		p := e.a.Add(d1.Mul(u))
+		*se = append(*se, bSplit{u: u, p: p})
+		*sf = append(*sf, bSplit{u: v, p: p})
+	case uIn: // an end point of f touches e
+		p := f.a
+		if v > 0.5 {
+			p = f.b
+		}
+		*se = append(*se, bSplit{u: u, p: p})
+	case vIn: // an end point of e touches f
+		p := e.a
+		if u > 0.5 {
+			p = e.b
+		}
+		*sf = append(*sf, bSplit{u: v, p: p})
+	}
+}
+
+// snapper merges points that are within booleanEps of each other
+type snapper struct {
+	cells map[[2]int64][]Point
+}
+
+func newSnapper() *snapper {
+	return &snapper{cells: make(map[[2]int64][]Point)}
+}
+
+// point returns the first point seen within booleanEps of p, or p itself
+func (s *snapper) point(p Point) Point {
+	const cell = booleanEps * 4
+	cx, cy := int64(math.Floor(p.X/cell)), int64(math.Floor(p.Y/cell))
+	for i := cx - 1; i <= cx+1; i++ {
+		for j := cy - 1; j <= cy+1; j++ {
+			for _, q := range s.cells[[2]int64{i, j}] {
+				if math.Abs(q.X-p.X) <= booleanEps && math.Abs(q.Y-p.Y) <= booleanEps {
+					return q
+				}
+			}
+		}
+	}
+	k := [2]int64{cx, cy}
+	s.cells[k] = append(s.cells[k], p)
+	return p
+}
+
+// chainEdges links the edges into closed loops. Where more than two edges
+// meet at a point, the loop turns as far left as possible, which keeps loops
+// that touch at a point separate.
+func chainEdges(edges []bEdge) (loops [][]bEdge) {
+	from := make(map[Point][]int)
+	for i, e := range edges {
+		from[e.a] = append(from[e.a], i)
+	}
+	used := make([]bool, len(edges))
+	for i := range edges {
+		if used[i] {
+			continue
+		}
+		var loop []bEdge
+		cur := i
+		for {
+			used[cur] = true
+			e := edges[cur]
+			loop = append(loop, e)
+			if e.b == loop[0].a {
+				break
+			}
+			din := e.b.Sub(e.a)
+			next, best := -1, math.Inf(-1)
+			for _, k := range from[e.b] {
+				if used[k] {
+					continue
+				}
+				dout := edges[k].b.Sub(edges[k].a)
+				if turn := math.Atan2(din.Cross(dout), din.Dot(dout)); turn > best {
+					next, best = k, turn
+				}
+			}
+			if next < 0 {
+				break // an open chain can only come from numerical trouble; close it
+			}
+			cur = next
+		}
+		loops = append(loops, loop)
+	}
+	return
+}
+
+// loopToSubpath converts a loop of edges to a closed subpath. Runs of
+// consecutive edges flattened from the same curve are replaced by
+// the matching piece of the curve.
+func loopToSubpath(loop []bEdge, curves []segment) subpath {
+	sp := subpath{start: loop[0].a, closed: true}
+	for i := 0; i < len(loop); {
+		e := loop[i]
+		j := i + 1
+		for j < len(loop) && loop[j].src == e.src && loop[j].t0 == loop[j-1].t1 &&
+			(loop[j].t1 > loop[j].t0) == (e.t1 > e.t0) {
+			j++
+		}
+		t0, t1 := e.t0, loop[j-1].t1
+		var s segment
+		if c := curves[e.src]; c.deg == 1 {
+			s = segment{deg: 1}
+		} else if t0 < t1 {
+			s = c.sub(t0, t1)
+		} else {
+			s = c.sub(t1, t0).reverse()
+		}
+		s.P[0], s.P[s.deg] = e.a, loop[j-1].b
+		sp.segs = append(sp.segs, s)
+		i = j
+	}
+	return sp
+}
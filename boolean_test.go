@@ -0,0 +1,154 @@
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+// Created 2018 by S.R.Wiley
+package rasterx_test
+
+import (
+	"image"
+	"math"
+	"strings"
+	"testing"
+
+	. "github.com/srwiley/rasterx"
+	"golang.org/x/image/colornames"
+)
+
+func getSquarePath(minX, minY, maxX, maxY float64) (p Path) {
+	AddRect(minX, minY, maxX, maxY, 0, &p)
+	return
+}
+
+// fillAlpha fills each of the paths in turn and returns the alpha channel
+func fillAlpha(wx, wy int, paths ...Path) []uint8 {
+	img := image.NewRGBA(image.Rect(0, 0, wx, wy))
+	scannerGV := NewScannerGV(wx, wy, img, img.Bounds())
+	f := NewFiller(wx, wy, scannerGV)
+	scannerGV.SetColor(colornames.Black)
+	for _, p := range paths {
+		p.AddTo(f)
+		f.Draw()
+		f.Clear()
+	}
+	alpha := make([]uint8, wx*wy)
+	for i := range alpha {
+		alpha[i] = img.Pix[i*4+3]
+	}
+	return alpha
+}
+
+// alphaDiff returns the largest difference in alpha and the number of differing pixels
+func alphaDiff(a, b []uint8) (maxDiff, count int) {
+	for i := range a {
+		d := int(a[i]) - int(b[i])
+		if d < 0 {
+			d = -d
+		}
+		if d > maxDiff {
+			maxDiff = d
+		}
+		if d > 8 {
+			count++
+		}
+	}
+	return
+}
+
+// edgeDiff returns the largest difference in alpha between the renders of a
+// region without overlaps and of overlapping pieces that fill it, and the
+// number of pixels that differ other than at the edges of the region. Where
+// the edges of two pieces cross a pixel, the rasterizer adds the parts of the
+// pixel each covers, and over-covers it by the part they share, which is no
+// more than the part the region covers.
+func edgeDiff(region, pieces []uint8) (maxDiff, count int) {
+	for i := range region {
+		d := int(pieces[i]) - int(region[i])
+		if d < -8 || d > int(region[i])+8 {
+			count++
+		}
+		if d < 0 {
+			d = -d
+		}
+		if d > maxDiff {
+			maxDiff = d
+		}
+	}
+	return
+}
+
+func TestBoolean(t *testing.T) {
+	const wx, wy = 200, 200
+	sq1 := getSquarePath(20, 20, 120, 120)
+	sq2 := getSquarePath(70, 70, 170, 170)
+	circle := getCirclePath(120, 70, 50)
+
+	union := Boolean(UnionOp, sq1, sq2, true)
+	if l := union.Length(); math.Abs(l-600) > 1e-6 {
+		t.Error("union perimeter wrong", l, union)
+	}
+	inter := Boolean(IntersectOp, sq1, sq2, true)
+	if l := inter.Length(); math.Abs(l-200) > 1e-6 {
+		t.Error("intersection perimeter wrong", l, inter)
+	}
+	diff := Boolean(DifferenceOp, sq1, sq2, true)
+	if l := diff.Length(); math.Abs(l-400) > 1e-6 {
+		t.Error("difference perimeter wrong", l, diff)
+	}
+	xor := Boolean(XorOp, sq1, sq2, true)
+	if n := len(xor.SubpathLengths()); n != 2 {
+		t.Error("xor should have two loops", n, xor)
+	}
+	empty := Boolean(IntersectOp, sq1, getSquarePath(150, 150, 190, 190), true)
+	if len(empty) != 0 {
+		t.Error("disjoint intersection not empty", empty)
+	}
+
+	// The result is free of overlaps, so it renders the same as the
+	// operands filled together with the nonzero rule.
+	cu := Boolean(UnionOp, sq1, circle, true)
+	if !strings.Contains(cu.String(), "C") {
+		t.Error("curves should be kept in the union", cu)
+	}
+	if m, n := alphaDiff(fillAlpha(wx, wy, sq1, circle), fillAlpha(wx, wy, cu)); n > 4 {
+		t.Error("union of square and circle renders differently", m, n)
+	}
+	// A doubled path has overlapping subpaths; the union cleans it up
+	var doubled Path
+	doubled = append(doubled, circle...)
+	doubled = append(doubled, circle...)
+	clean := Boolean(UnionOp, doubled, nil, true)
+	if n := len(clean.SubpathLengths()); n != 1 {
+		t.Error("cleaned doubled circle should have one loop", n)
+	}
+	ring := Boolean(DifferenceOp, getCirclePath(100, 100, 80), getCirclePath(100, 100, 40), false)
+	// The hole of the ring winds opposite to the outside, so it stays empty
+	// when filled with the nonzero rule.
+	ra := fillAlpha(wx, wy, ring)
+	if ra[100*wx+100] != 0 || ra[40*wx+100] != 255 {
+		t.Error("ring fill wrong", ra[100*wx+100], ra[40*wx+100])
+	}
+}
+
+func TestBooleanStrokeOutline(t *testing.T) {
+	const wx, wy = 300, 300
+	// The union of a stroke outline renders as the outline itself, where
+	// nearly straight joins, as those of a circle, leave no flaps in it
+	circle := getCirclePath(150, 150, 120)
+	for i, c := range []struct {
+		width float64
+		jm    JoinMode
+		gp    GapFunc
+	}{{16, ArcClip, RoundGap}, {16, Arc, CubicGap}, {30, ArcClip, QuadraticGap}} {
+		s := NewStroker(wx, wy, NewScannerGV(wx, wy, image.NewRGBA(image.Rect(0, 0, 1, 1)), image.Rect(0, 0, 1, 1)))
+		s.SetStroke(ToFixed(c.width), 4*64, RoundCap, nil, c.gp, c.jm)
+		o := s.StrokeOutline(circle)
+		if m, n := alphaDiff(fillAlpha(wx, wy, Boolean(UnionOp, o, nil, true)), fillAlpha(wx, wy, o)); n > 0 {
+			t.Error("union of circle outline renders differently", i, m, n)
+		}
+	}
+	// Narrow dashes around a hairpin, where the pieces meet at shallow angles
+	d := NewDasher(wx, wy, NewScannerGV(wx, wy, image.NewRGBA(image.Rect(0, 0, 1, 1)), image.Rect(0, 0, 1, 1)))
+	d.SetStroke(2*64, 4*64, RoundCap, nil, RoundGap, Arc, []float64{20, 10}, 0)
+	o := d.StrokeOutline(getOpenCubicPath())
+	if m, n := edgeDiff(fillAlpha(wx, wy, Boolean(UnionOp, o, nil, true)), fillAlpha(wx, wy, o)); n > 0 {
+		t.Error("union of dashed outline renders differently", m, n)
+	}
+}
@@ -0,0 +1,111 @@
+// Triangle, arrow and template caps and gaps
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"math"
+
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	// TriangleCap caps lines with a triangle that comes to a point half the
+	// stroke width past the end of the line
+	TriangleCap CapFunc = func(p Adder, a, eNorm fixed.Point26_6) {
+		p.Start(a.Add(eNorm))
+		p.Line(a.Add(turnStarboard90(eNorm)))
+		p.Line(a.Sub(eNorm))
+	}
+	// InvertedTriangleCap caps lines with a square with a triangular notch
+	// cut into it, like the tail of a swallow, so the end of the line is a
+	// V that points back along the line
+	InvertedTriangleCap CapFunc = func(p Adder, a, eNorm fixed.Point26_6) {
+		tpt := a.Add(turnStarboard90(eNorm))
+		p.Start(a.Add(eNorm))
+		p.Line(tpt.Add(eNorm))
+		p.Line(a)
+		p.Line(tpt.Sub(eNorm))
+		p.Line(a.Sub(eNorm))
+	}
+)
+
+// ArrowCap returns a CapFunc that caps lines with an arrowhead. The head
+// extends length times the stroke width past the end of the line, and its
+// sides make the angle, in radians, with the line. The base of the head is
+// at the end of the line, and is never narrower than the stroke.
+func ArrowCap(length, angle float64) CapFunc {
+	return func(p Adder, a, eNorm fixed.Point26_6) {
+		u := Length(eNorm)
+		l := fixed.Int26_6(length * 2 * float64(u))
+		hw := fixed.Int26_6(float64(l) * math.Tan(angle))
+		if hw < u || angle >= math.Pi/2 {
+			hw = u
+		}
+		side := ToLength(eNorm, hw)
+		p.Start(a.Add(eNorm))
+		p.Line(a.Add(side))
+		p.Line(a.Add(ToLength(turnStarboard90(eNorm), l)))
+		p.Line(a.Sub(side))
+		p.Line(a.Sub(eNorm))
+	}
+}
+
+// GapFromPath returns a GapFunc that bridges gaps with the first subpath of
+// template. The template is drawn in a frame with the point of the join at
+// (0, 0), in units of half the stroke width, where it should run from
+// (0, 1) to (0, -1), and (1, 0) is the point of a round join. The frame
+// is fitted to each join so that (0, 1) and (0, -1) fall on the ends of
+// the trailing and leading normals, and (1, 0) on the bisector of the
+// normals at half the stroke width from the join. The template is joined
+// to the ends of the normals with lines.
+func GapFromPath(template Path) GapFunc {
+	sps := template.subpaths()
+	if len(sps) == 0 {
+		return FlatGap
+	}
+	sp := sps[0]
+	return func(p Adder, a, tNorm, lNorm fixed.Point26_6) {
+		ap, tn, ln := ToPoint(a), ToPoint(tNorm), ToPoint(lNorm)
+		u := tn.Len()
+		if u == 0 {
+			p.Line(a.Add(lNorm))
+			return
+		}
+		out := tn.Add(ln)
+		if out.Len() < 1e-9*u { // opposite normals, as for a cap
+			out = Point{-tn.Y, tn.X}
+		}
+		out = out.Unit()
+		cosH := tn.Dot(out) / u
+		side := tn.Sub(out.Mul(tn.Dot(out))) // y axis, toward the trailing normal
+		m := Matrix2D{A: out.X * u * (1 - cosH), B: out.Y * u * (1 - cosH),
+			C: side.X, D: side.Y,
+			E: ap.X + out.X*u*cosH, F: ap.Y + out.Y*u*cosH}
+		pt := func(q Point) Point {
+			x, y := m.Transform(q.X, q.Y)
+			return Point{x, y}
+		}
+		p.Line(pt(sp.start).Fixed())
+		for _, s := range sp.segs {
+			for i := 1; i <= s.deg; i++ {
+				s.P[i] = pt(s.P[i])
+			}
+			s.addTo(p)
+		}
+		p.Line(a.Add(lNorm))
+	}
+}
+
+// CapFromPath returns a CapFunc that caps lines with the first subpath of
+// template, which is drawn in a frame with the end of the line at (0, 0),
+// x pointing out of the line, and y along the end normal, in units of half
+// the stroke width. The template should run from (0, 1) to (0, -1); for
+// example, a SquareCap is M0,1 L1,1 L1,-1 L0,-1. It is joined to the
+// corners of the line end with lines.
+func CapFromPath(template Path) CapFunc {
+	gf := GapFromPath(template)
+	return func(p Adder, a, eNorm fixed.Point26_6) {
+		GapToCap(p, a, eNorm, gf)
+	}
+}
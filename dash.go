@@ -5,6 +5,8 @@
 package rasterx
 
 import (
+	"math"
+
 	"golang.org/x/image/math/fixed"
 )
 
@@ -12,13 +14,60 @@ import (
 // dashed lines with end capping
 type Dasher struct {
 	Stroker
-	Dashes                    []fixed.Int26_6
-	dashPlace                 int
-	firstDashIsGap, dashIsGap bool
-	deltaDash, DashOffset     fixed.Int26_6
-	sgm                       Rasterx
+	Dashes []fixed.Int26_6
+	dashPos
+	firstDashIsGap bool
+	DashOffset     fixed.Int26_6
+	sgm            Rasterx
 	// sgm allows us to switch between dashing
 	// and non-dashing rasterizers in the SetStroke function.
+	// setDashes and setOffset are the dash array and offset given to
+	// SetStroke, before SetPathLength scales them
+	setDashes []fixed.Int26_6
+	setOffset fixed.Int26_6
+	// curveScale is the ratio of the arc length of the curve being dashed to
+	// the length of the lines it is flattened into, or 0 for a line, and
+	// curveRem is the part of the arc length lost to rounding so far
+	curveScale, curveRem float64
+}
+
+// dashPos is a place in a dash pattern, shared by the Dasher and Path.dash
+// so that both walk the pattern alike
+type dashPos struct {
+	dashPlace int           // index of the current dash or gap
+	deltaDash fixed.Int26_6 // length of the current dash or gap passed
+	dashIsGap bool
+}
+
+// startAt moves to offset into the pattern dashes, at least one of which must
+// be greater than zero. The offset wraps around the period of the pattern,
+// which is repeated twice if it has an odd number of values, so a negative
+// offset starts that far before the start of the pattern.
+func (d *dashPos) startAt(dashes []fixed.Int26_6, offset fixed.Int26_6) {
+	var period fixed.Int26_6
+	for _, v := range dashes {
+		period += v
+	}
+	if len(dashes)%2 == 1 {
+		period *= 2
+	}
+	*d = dashPos{deltaDash: offset % period}
+	if d.deltaDash < 0 {
+		d.deltaDash += period
+	}
+	for d.deltaDash > dashes[d.dashPlace] {
+		d.deltaDash -= dashes[d.dashPlace]
+		d.next(len(dashes))
+	}
+}
+
+// next moves on to the next dash or gap of a pattern of n values
+func (d *dashPos) next(n int) {
+	d.dashIsGap = !d.dashIsGap
+	d.dashPlace++
+	if d.dashPlace == n {
+		d.dashPlace = 0
+	}
 }
 
 // joinF overides stroker joinF during dashed stroking, because we need to slightly modify
@@ -31,19 +80,13 @@ func (r *Dasher) joinF() {
 
 // Start starts a dashed line
 func (r *Dasher) Start(a fixed.Point26_6) {
+	if r.buffering() {
+		r.bufferStart(r, a)
+		return
+	}
 	// Advance dashPlace to the dashOffset start point and set deltaDash
 	if len(r.Dashes) > 0 {
-		r.deltaDash = r.DashOffset
-		r.dashIsGap = false
-		r.dashPlace = 0
-		for r.deltaDash > r.Dashes[r.dashPlace] {
-			r.deltaDash -= r.Dashes[r.dashPlace]
-			r.dashIsGap = !r.dashIsGap
-			r.dashPlace++
-			if r.dashPlace == len(r.Dashes) {
-				r.dashPlace = 0
-			}
-		}
+		r.startAt(r.Dashes, r.DashOffset)
 		r.firstDashIsGap = r.dashIsGap
 	}
 	r.Stroker.Start(a)
@@ -57,6 +100,14 @@ func (r *Dasher) lineF(b fixed.Point26_6) {
 	ba := b.Sub(a)
 	segLen := Length(ba)
 	var nlt fixed.Int26_6
+	if r.curveScale > 0 { // measure along the curve rather than its lines
+		l := math.Hypot(float64(ba.X), float64(ba.Y))*r.curveScale + r.curveRem
+		segLen = fixed.Int26_6(math.Round(l))
+		r.curveRem = l - float64(segLen)
+	}
+	arcLen := segLen
+	at := r.w.at
+	r.walkTo(a, b)
 	if b == r.leadPoint.P { // End of segment
 		bnorm = r.leadPoint.TNorm // Use more accurate leadPoint tangent
 	} else {
@@ -65,14 +116,18 @@ func (r *Dasher) lineF(b fixed.Point26_6) {
 	for segLen+r.deltaDash > r.Dashes[r.dashPlace] {
 		nl := r.Dashes[r.dashPlace] - r.deltaDash
 		nlt += nl
-		r.dashLineStrokeBit(a.Add(ToLength(ba, nlt)), bnorm, false)
-		r.dashIsGap = !r.dashIsGap
+		cnorm := bnorm
+		if r.w.fn != nil && segLen > 0 { // width at the end of the dash
+			cnorm = turnPort90(ToLength(ba, r.halfWidthAt(at+(r.w.at-at)*float64(nlt)/float64(arcLen))))
+		}
+		cut := nlt
+		if arcLen > 0 && arcLen != Length(ba) {
+			cut = fixed.Int26_6(int64(nlt) * int64(Length(ba)) / int64(arcLen))
+		}
+		r.dashLineStrokeBit(a.Add(ToLength(ba, cut)), cnorm, false)
 		segLen -= nl
 		r.deltaDash = 0
-		r.dashPlace++
-		if r.dashPlace == len(r.Dashes) {
-			r.dashPlace = 0
-		}
+		r.next(len(r.Dashes))
 	}
 	r.deltaDash += segLen
 	r.dashLineStrokeBit(b, bnorm, true)
@@ -87,6 +142,7 @@ func (r *Dasher) SetStroke(width, miterLimit fixed.Int26_6, capL, capT CapFunc,
 	r.Stroker.SetStroke(width, miterLimit, capL, capT, gp, jm)
 
 	r.Dashes = r.Dashes[:0] // clear the dash array
+	r.setDashes = r.setDashes[:0]
 	if len(dashes) == 0 {
 		r.sgm = &r.Stroker // This is just plain stroking
 		return
@@ -109,22 +165,61 @@ func (r *Dasher) SetStroke(width, miterLimit fixed.Int26_6, capL, capT CapFunc,
 		return
 	}
 	r.DashOffset = fixed.Int26_6(dashOffset * 64)
+	r.setDashes = append(r.setDashes[:0], r.Dashes...)
+	r.setOffset = r.DashOffset
 	r.sgm = r // Use the full dasher
 }
 
+// SetPathLength scales the dash array and offset set by SetStroke so that they
+// are relative to pathLength, the author's length for the path p, as with the SVG
+// pathLength attribute. It should be called after SetStroke, and replaces the
+// scale of any earlier call. A pathLength of zero or less removes the scale.
+// The length of p is its arc length as measured by PathMeasure, which is
+// also how the Dasher measures dashes along curves. If every dash scales to
+// less than a fixed point unit, the path is stroked without dashes, as
+// SetStroke does for a dash array with no dash greater than zero.
+func (r *Dasher) SetPathLength(p Path, pathLength float64) {
+	if len(r.setDashes) == 0 {
+		return
+	}
+	scale := 1.0
+	if pathLength > 0 {
+		scale = p.Length() / pathLength
+	}
+	r.Dashes = r.Dashes[:0]
+	oneIsPos := false
+	for _, v := range r.setDashes {
+		fv := fixed.Int26_6(float64(v) * scale)
+		oneIsPos = oneIsPos || fv > 0
+		r.Dashes = append(r.Dashes, fv)
+	}
+	if !oneIsPos {
+		r.Dashes = r.Dashes[:0]
+		r.sgm = &r.Stroker // This is just plain stroking
+		return
+	}
+	r.DashOffset = fixed.Int26_6(float64(r.setOffset) * scale)
+	r.sgm = r
+}
+
 //Stop terminates a dashed line
 func (r *Dasher) Stop(isClosed bool) {
+	if r.buffering() {
+		r.strokeBuffered(r, isClosed)
+		return
+	}
 	if len(r.Dashes) == 0 {
 		r.Stroker.Stop(isClosed)
 		return
 	}
+	isClosed = r.stopZeroLength(r.sgm, isClosed)
 	if r.inStroke == false {
 		return
 	}
 	if isClosed && r.a != r.firstP.P {
 		r.LineSeg(r.sgm, r.firstP.P)
 	}
-	ra := &r.Filler
+	ra := r.sink()
 	if isClosed && !r.firstDashIsGap && !r.dashIsGap { // closed connect w/o caps
 		a := r.a
 		r.firstP.TNorm = r.leadPoint.TNorm
@@ -139,6 +234,9 @@ func (r *Dasher) Stop(isClosed bool) {
 	} else { // Cap open ends
 		if !r.dashIsGap {
 			r.CapL(ra, r.leadPoint.P, r.leadPoint.TNorm)
+		} else if r.zeroDashAtEnd() { // a dot at the end of the path
+			r.CapT(ra, r.leadPoint.P, Invert(r.leadPoint.TNorm))
+			r.CapL(ra, r.leadPoint.P, r.leadPoint.TNorm)
 		}
 		if !r.firstDashIsGap {
 			r.CapT(ra, r.firstP.P, Invert(r.firstP.LNorm))
@@ -147,12 +245,22 @@ func (r *Dasher) Stop(isClosed bool) {
 	r.inStroke = false
 }
 
+// zeroDashAtEnd reports if the gap in which the path ends finishes at the
+// end, and is followed by a dash of zero length, which is drawn as a dot
+func (r *Dasher) zeroDashAtEnd() bool {
+	next := r.dashPlace + 1
+	if next == len(r.Dashes) {
+		next = 0
+	}
+	return r.Dashes[next] == 0 && r.Dashes[r.dashPlace]-r.deltaDash <= 1
+}
+
 // dashLineStrokeBit is a helper function that reduces code redundancey in the
 // lineF function.
 func (r *Dasher) dashLineStrokeBit(b, bnorm fixed.Point26_6, dontClose bool) {
 	if !r.dashIsGap { // Moving from dash to gap
 		a := r.a
-		ra := &r.Filler
+		ra := r.sink()
 		ra.Start(b.Sub(bnorm))
 		ra.Line(a.Sub(r.ln))
 		ra.Start(a.Add(r.ln))
@@ -162,7 +270,7 @@ func (r *Dasher) dashLineStrokeBit(b, bnorm fixed.Point26_6, dontClose bool) {
 		}
 	} else { // Moving from gap to dash
 		if dontClose == false {
-			ra := &r.Filler
+			ra := r.sink()
 			r.CapT(ra, b, Invert(bnorm))
 		}
 	}
@@ -172,19 +280,76 @@ func (r *Dasher) dashLineStrokeBit(b, bnorm fixed.Point26_6, dontClose bool) {
 
 // Line for Dasher is here to pass the dasher sgm to LineP
 func (r *Dasher) Line(b fixed.Point26_6) {
+	if r.buffering() {
+		r.w.buf.Line(b)
+		return
+	}
 	r.LineSeg(r.sgm, b)
 }
 
 // QuadBezier for dashing
 func (r *Dasher) QuadBezier(b, c fixed.Point26_6) {
+	if r.buffering() {
+		r.w.buf.QuadBezier(b, c)
+		return
+	}
+	if r.sgm == Rasterx(r) {
+		r.curveScale = r.arcScale(b, c)
+	}
 	r.quadBezierf(r.sgm, b, c)
+	r.curveScale = 0
 }
 
 // CubeBezier starts a stroked cubic bezier.
 // It is a low level function exposed for the purposes of callbacks
 // and debugging.
 func (r *Dasher) CubeBezier(b, c, d fixed.Point26_6) {
+	if r.buffering() {
+		r.w.buf.CubeBezier(b, c, d)
+		return
+	}
+	if r.sgm == Rasterx(r) {
+		r.curveScale = r.arcScale(b, c, d)
+	}
 	r.cubeBezierf(r.sgm, b, c, d)
+	r.curveScale = 0
+}
+
+// arcScale returns the ratio of the arc length of the curve from the current
+// point through the control points pts to the length of the lines the Filler
+// flattens it into, so that dashes can be measured along the curve itself.
+func (r *Dasher) arcScale(pts ...fixed.Point26_6) float64 {
+	s := segment{P: [4]Point{{float64(r.a.X), float64(r.a.Y)}}, deg: len(pts)}
+	for i, p := range pts {
+		s.P[i+1] = Point{float64(p.X), float64(p.Y)}
+	}
+	var flat float64
+	a := r.a
+	lineTo := func(b fixed.Point26_6) {
+		flat += math.Hypot(float64(b.X-a.X), float64(b.Y-a.Y))
+		a = b
+	}
+	switch {
+	case r.tol > 0:
+		s.flatten(r.flattenCount(r.sgm, s), func(x, y float64) {
+			lineTo(fixed.Point26_6{X: fixed.Int26_6(x), Y: fixed.Int26_6(y)})
+		})
+	case s.deg == 2:
+		QuadTo(float32(s.P[0].X), float32(s.P[0].Y), float32(s.P[1].X), float32(s.P[1].Y),
+			float32(s.P[2].X), float32(s.P[2].Y), func(x, y float32) {
+				lineTo(fixed.Point26_6{X: fixed.Int26_6(x), Y: fixed.Int26_6(y)})
+			})
+	default:
+		CubeTo(float32(s.P[0].X), float32(s.P[0].Y), float32(s.P[1].X), float32(s.P[1].Y),
+			float32(s.P[2].X), float32(s.P[2].Y), float32(s.P[3].X), float32(s.P[3].Y), func(x, y float32) {
+				lineTo(fixed.Point26_6{X: fixed.Int26_6(x), Y: fixed.Int26_6(y)})
+			})
+	}
+	r.curveRem = 0
+	if flat <= 0 {
+		return 0
+	}
+	return s.length() / flat
 }
 
 // NewDasher returns a Dasher ptr with default values.
@@ -200,3 +365,88 @@ func NewDasher(width, height int, scanner Scanner) *Dasher {
 	r.sgm = &r.Stroker
 	return r
 }
+
+// dash returns the dashes of the path as open subpaths, for the dash array
+// and offset of a Dasher, walking the pattern as the Dasher does but by arc
+// length. Where a dash of a closed subpath runs across its start point, the
+// two parts are joined into one dash. A dash of zero length is kept as a line
+// about one fixed point unit long in the direction of the path, so that a pen
+// draws its caps there as a dot, and a subpath of zero length is kept as it
+// is. The path is returned unchanged if no dash is greater than 0. A shaped
+// pen is swept along the dashes, since it cannot be dashed as it is stroked.
+func (p Path) dash(dashes []fixed.Int26_6, offset fixed.Int26_6) (q Path) {
+	oneIsPos := false
+	for _, v := range dashes {
+		oneIsPos = oneIsPos || v > 0
+	}
+	if !oneIsPos {
+		return append(q, p...)
+	}
+	const unit = 1.0 / 64 // a fixed point unit in pixels
+	m := NewPathMeasure(p)
+	for i, sp := range m.sps {
+		spLen := m.spLens[i]
+		if spLen == 0 {
+			q.Start(sp.start.Fixed())
+			for _, s := range sp.segs {
+				s.addTo(&q)
+			}
+			q.Stop(sp.closed)
+			continue
+		}
+		var pos dashPos
+		pos.startAt(dashes, offset)
+		var ps pieces
+		l := -float64(pos.deltaDash) / 64 // the start of the current dash or gap
+		startsOn, endsOn := false, false
+		for {
+			l1 := l + float64(dashes[pos.dashPlace])/64
+			switch {
+			case pos.dashIsGap:
+			case l1 == l:
+				ps = append(ps, m.dot(i, l))
+			case l1 > 0:
+				startsOn = startsOn || l <= 0
+				endsOn = l1 >= spLen
+				ps = append(ps, m.extract(i, math.Max(l, 0), math.Min(l1, spLen)))
+			}
+			l = l1
+			pos.next(len(dashes))
+			// a dash of zero length at the end is drawn, as by the Dasher
+			if l >= spLen && !(l < spLen+unit && !pos.dashIsGap && dashes[pos.dashPlace] == 0) {
+				break
+			}
+		}
+		if n := len(ps); sp.closed && startsOn && endsOn {
+			if n == 1 { // one dash covers the subpath
+				ps[0] = sp
+			} else if len(ps[0].segs) > 0 {
+				ps[n-1].segs = append(ps[n-1].segs, ps[0].segs...)
+				ps = ps[1:]
+			}
+		}
+		ps.addTo(&q)
+	}
+	return
+}
+
+// dot returns a line at length l along subpath i for a dash of zero length,
+// from a point on a fixed point unit to the next unit in the direction of the
+// subpath there, so that it keeps its direction in fixed point
+func (m *PathMeasure) dot(i int, l float64) subpath {
+	sp, lens := m.sps[i], m.segLens[i]
+	j := 0
+	for ; j < len(lens)-1 && (l > lens[j] || lens[j] == 0); j++ {
+		l -= lens[j]
+	}
+	s := sp.segs[j]
+	t := s.paramAt(l, lens[j])
+	a := ToPoint(s.eval(t).Fixed())
+	d := s.tangent(t)
+	step := Point{1.0 / 64, 0}
+	if k := math.Max(math.Abs(d.X), math.Abs(d.Y)); k > 0 {
+		step = d.Mul(1 / (64 * k))
+	}
+	b := a.Add(step)
+	return subpath{start: a, segs: []segment{{P: [4]Point{a, b}, deg: 1}}}
+}
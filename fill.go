@@ -55,6 +55,7 @@ type (
 	Filler struct {
 		Scanner
 		a, first fixed.Point26_6
+		tol      float64 // flattening tolerance in pixels; 0 uses QuadTo and CubeTo
 	}
 )
 
@@ -131,6 +132,107 @@ func CubeTo(ax, ay, bx, by, cx, cy, dx, dy float32, LineTo func(ex, ey float32))
 	LineTo(dx, dy)
 }
 
+// QuadToTol flattens the quadratic Bezier curve into lines through the LineTo func.
+// The number of lines is the fewest that keep the lines within tol of the curve
+// according to Wang's formula, so the error is bounded for any curve.
+func QuadToTol(ax, ay, bx, by, cx, cy, tol float64, LineTo func(dx, dy float64)) {
+	s := segment{P: [4]Point{{ax, ay}, {bx, by}, {cx, cy}}, deg: 2}
+	s.flatten(s.flattenCount(tol), LineTo)
+}
+
+// CubeToTol flattens the cubic Bezier curve into lines through the LineTo func.
+// The number of lines is the fewest that keep the lines within tol of the curve
+// according to Wang's formula, so the error is bounded for any curve.
+func CubeToTol(ax, ay, bx, by, cx, cy, dx, dy, tol float64, LineTo func(ex, ey float64)) {
+	s := segment{P: [4]Point{{ax, ay}, {bx, by}, {cx, cy}, {dx, dy}}, deg: 3}
+	s.flatten(s.flattenCount(tol), LineTo)
+}
+
+// flatten sends n evenly spaced points of the segment, after the start, to LineTo.
+// The last point is exactly the end point.
+func (s segment) flatten(n int, LineTo func(x, y float64)) {
+	for i := 1; i < n; i++ {
+		p := s.eval(float64(i) / float64(n))
+		LineTo(p.X, p.Y)
+	}
+	e := s.end()
+	LineTo(e.X, e.Y)
+}
+
+// strokeFlattenCount returns the number of lines needed to flatten the segment
+// so that both the segment and its offsets by u on either side are within tol.
+// The lines of an offset are farther from it than the segment lines are from the
+// segment, by up to u*(1-cos(θ/2)) for lines that turn by θ, so half of tol is
+// given to the segment and the count is raised until the turn of each line
+// keeps the rest within the other half. The turning of the control polygon
+// bounds the turning of the segment.
+func (s segment) strokeFlattenCount(u, tol float64) int {
+	tol /= 2
+	n := s.flattenCount(tol)
+	if u <= tol {
+		return n
+	}
+	var turn float64
+	var last Point
+	for i := 1; i <= s.deg; i++ {
+		d := s.P[i].Sub(s.P[i-1])
+		if d.X == 0 && d.Y == 0 {
+			continue
+		}
+		if last.X != 0 || last.Y != 0 {
+			turn += math.Abs(math.Atan2(last.Cross(d), last.Dot(d)))
+		}
+		last = d
+	}
+	if m := int(math.Ceil(turn / (2 * math.Acos(1-tol/u)))); m > n {
+		return m
+	}
+	return n
+}
+
+// SetTolerance sets the maximum distance in pixels between curves and the lines
+// that approximate them when flattened. Smaller values give more accurate
+// curves at the cost of speed. For a Stroker or Dasher, the tolerance also
+// applies to the offset edges of stroked curves. A tolerance of zero or less
+// restores the default flattening of QuadTo and CubeTo.
+func (r *Filler) SetTolerance(tol float64) {
+	if tol < 0 {
+		tol = 0
+	}
+	r.tol = tol
+}
+
+// SetToleranceFor sets the flattening tolerance for output that will be
+// transformed by m after flattening, such as a path captured with
+// StrokeOutline and drawn at another scale. The tolerance is divided by the
+// largest scale factor of m so that the error stays within tol pixels after
+// the transform.
+func (r *Filler) SetToleranceFor(tol float64, m Matrix2D) {
+	if s := m.MaxScale(); s > 0 {
+		tol /= s
+	}
+	r.SetTolerance(tol)
+}
+
+// Tolerance returns the flattening tolerance in pixels, or 0 if the default
+// flattening is used.
+func (r *Filler) Tolerance() float64 {
+	return r.tol
+}
+
+// flattenCount returns the number of lines to flatten the segment, in fixed point
+// units, into when sent to sgm.
+func (r *Filler) flattenCount(sgm Rasterx, s segment) int {
+	tol := r.tol * 64
+	switch q := sgm.(type) {
+	case *Stroker:
+		return s.strokeFlattenCount(float64(q.u), tol)
+	case *Dasher:
+		return s.strokeFlattenCount(float64(q.u), tol)
+	}
+	return s.flattenCount(tol)
+}
+
 // devSquared returns a measure of how curvy the sequence (ax, ay) to (bx, by)
 // to (cx, cy) is. It determines how many line segments will approximate a
 // Bézier curve segment. This functions is copied from the version found in
@@ -160,6 +262,14 @@ func (r *Filler) QuadBezierF(sgm Rasterx, b, c fixed.Point26_6) {
 		return
 	}
 	sgm.joinF()
+	if r.tol > 0 {
+		s := segment{P: [4]Point{{float64(r.a.X), float64(r.a.Y)}, {float64(b.X), float64(b.Y)},
+			{float64(c.X), float64(c.Y)}}, deg: 2}
+		s.flatten(r.flattenCount(sgm, s), func(dx, dy float64) {
+			sgm.lineF(fixed.Point26_6{X: fixed.Int26_6(dx), Y: fixed.Int26_6(dy)})
+		})
+		return
+	}
 	QuadTo(float32(r.a.X), float32(r.a.Y), // Pts are x64, but does not matter.
 		float32(b.X), float32(b.Y),
 		float32(c.X), float32(c.Y),
@@ -199,6 +309,14 @@ func (r *Filler) CubeBezierF(sgm Rasterx, b, c, d fixed.Point26_6) {
 		return
 	}
 	sgm.joinF()
+	if r.tol > 0 {
+		s := segment{P: [4]Point{{float64(r.a.X), float64(r.a.Y)}, {float64(b.X), float64(b.Y)},
+			{float64(c.X), float64(c.Y)}, {float64(d.X), float64(d.Y)}}, deg: 3}
+		s.flatten(r.flattenCount(sgm, s), func(ex, ey float64) {
+			sgm.lineF(fixed.Point26_6{X: fixed.Int26_6(ex), Y: fixed.Int26_6(ey)})
+		})
+		return
+	}
 	CubeTo(float32(r.a.X), float32(r.a.Y),
 		float32(b.X), float32(b.Y),
 		float32(c.X), float32(c.Y),
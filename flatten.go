@@ -0,0 +1,109 @@
+// Flattening of paths to polylines
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"math"
+)
+
+// collinearEps is the largest sine of the angle between two consecutive
+// polyline edges for the point between them to count as collinear
+const collinearEps = 1e-9
+
+// Polyline is a subpath of a path approximated by lines, in pixel
+// coordinates. The last point of a closed polyline repeats its first, as
+// with polygon rings in GIS formats, so Closed tells it apart from an open
+// subpath that ends where it starts.
+type Polyline struct {
+	Points []Point
+	Closed bool
+}
+
+// Flatten approximates the path with polylines, one per subpath, in pixel
+// coordinates. Curves are replaced by lines that are within tol of them, or
+// by the same lines the Filler uses if tol is zero or less. If dropCollinear
+// is true, repeated points and points in the middle of straight runs are
+// removed.
+func (p Path) Flatten(tol float64, dropCollinear bool) []Polyline {
+	sps := p.subpaths()
+	polys := make([]Polyline, 0, len(sps))
+	for _, sp := range sps {
+		poly := sp.polyline(tol)
+		if dropCollinear {
+			poly = dropCollinearPoints(poly, sp.closed)
+		}
+		polys = append(polys, Polyline{Points: poly, Closed: sp.closed})
+	}
+	return polys
+}
+
+// polyline returns the points of the subpath flattened within tol, or
+// flattened as by the Filler if tol is zero or less. The last point of a
+// closed subpath is its start point.
+func (sp subpath) polyline(tol float64) []Point {
+	poly := []Point{sp.start}
+	lineTo := func(x, y float64) {
+		poly = append(poly, Point{x, y})
+	}
+	for _, s := range sp.segs {
+		switch {
+		case s.deg == 1:
+			poly = append(poly, s.P[1])
+		case tol > 0:
+			s.flatten(s.flattenCount(tol), lineTo)
+		default:
+			s.flattenDefault(lineTo)
+		}
+	}
+	if sp.closed && poly[len(poly)-1] != sp.start {
+		poly = append(poly, sp.start)
+	}
+	return poly
+}
+
+// flattenDefault flattens a curved segment with QuadTo or CubeTo in fixed
+// point units, which gives the same lines as the Filler with no tolerance set.
+func (s segment) flattenDefault(LineTo func(x, y float64)) {
+	lineTo := func(x, y float32) {
+		LineTo(float64(x)/64, float64(y)/64)
+	}
+	var c [8]float32
+	for i := 0; i <= s.deg; i++ {
+		c[2*i], c[2*i+1] = float32(s.P[i].X*64), float32(s.P[i].Y*64)
+	}
+	if s.deg == 2 {
+		QuadTo(c[0], c[1], c[2], c[3], c[4], c[5], lineTo)
+		return
+	}
+	CubeTo(c[0], c[1], c[2], c[3], c[4], c[5], c[6], c[7], lineTo)
+}
+
+// dropCollinearPoints removes repeated points and points that lie on a
+// straight line between their neighbors from the polyline. A point where the
+// line doubles back is kept. If closed, the first and last points are the same
+// point, and it is also removed if it is in the middle of a straight run.
+func dropCollinearPoints(poly []Point, closed bool) []Point {
+	out := poly[:1]
+	for _, q := range poly[1:] {
+		if q == out[len(out)-1] {
+			continue
+		}
+		if n := len(out); n > 1 && isCollinear(out[n-2], out[n-1], q) {
+			out[n-1] = q
+			continue
+		}
+		out = append(out, q)
+	}
+	if closed && len(out) > 4 && isCollinear(out[len(out)-2], out[0], out[1]) {
+		out[0] = out[len(out)-2]
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// isCollinear returns true if b lies on the line from a to c between them
+func isCollinear(a, b, c Point) bool {
+	ab, bc := b.Sub(a), c.Sub(b)
+	return ab.Dot(bc) > 0 && math.Abs(ab.Cross(bc)) <= collinearEps*ab.Len()*bc.Len()
+}
@@ -0,0 +1,144 @@
+// Glyph outlines from sfnt fonts
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// FontFace sends the outlines of glyphs of an sfnt.Font (TrueType or
+// OpenType) at a given size to an Adder, so that text can be filled,
+// stroked or dashed like any other path. Text space has its origin on the
+// baseline at the start of the text, with x increasing to the right and y
+// increasing down, and is mapped to the image by a Matrix2D. A FontFace is
+// not safe for concurrent use.
+type FontFace struct {
+	Font *sfnt.Font
+	Size float64 // the em size in pixels of text space
+	buf  sfnt.Buffer
+}
+
+// NewFontFace returns a FontFace for the font at the given em size in pixels
+func NewFontFace(f *sfnt.Font, size float64) *FontFace {
+	return &FontFace{Font: f, Size: size}
+}
+
+// unitsPPEM returns the ppem at which sfnt gives values in font units,
+// which keeps the full precision of the font before scaling
+func (f *FontFace) unitsPPEM() fixed.Int26_6 {
+	return fixed.Int26_6(f.Font.UnitsPerEm()) << 6
+}
+
+// scale returns the factor from font units to text space
+func (f *FontFace) scale() float64 {
+	return f.Size / float64(f.Font.UnitsPerEm())
+}
+
+// GlyphIndex returns the index of the glyph for the rune r, which is 0, the
+// missing glyph, if the font has no glyph for r.
+func (f *FontFace) GlyphIndex(r rune) (sfnt.GlyphIndex, error) {
+	return f.Font.GlyphIndex(&f.buf, r)
+}
+
+// GlyphAdvance returns the advance width of the glyph in text space
+func (f *FontFace) GlyphAdvance(x sfnt.GlyphIndex) (float64, error) {
+	adv, err := f.Font.GlyphAdvance(&f.buf, x, f.unitsPPEM(), font.HintingNone)
+	return float64(adv) / 64 * f.scale(), err
+}
+
+// Kern returns the kerning adjustment in text space between the glyphs x0
+// and x1, or 0 if the font has none for the pair
+func (f *FontFace) Kern(x0, x1 sfnt.GlyphIndex) (float64, error) {
+	k, err := f.Font.Kern(&f.buf, x0, x1, f.unitsPPEM(), font.HintingNone)
+	if err == sfnt.ErrNotFound {
+		return 0, nil
+	}
+	return float64(k) / 64 * f.scale(), err
+}
+
+// AddGlyph sends the outline of the glyph x, with its origin at the origin
+// of text space, to q after mapping it by m. Each contour is a closed
+// subpath. It returns the advance width of the glyph in text space.
+func (f *FontFace) AddGlyph(q Adder, x sfnt.GlyphIndex, m Matrix2D) (float64, error) {
+	segs, err := f.Font.LoadGlyph(&f.buf, x, f.unitsPPEM(), nil)
+	if err != nil {
+		return 0, err
+	}
+	m = m.Scale(f.scale(), f.scale())
+	pt := func(p fixed.Point26_6) fixed.Point26_6 {
+		x, y := m.Transform(float64(p.X)/64, float64(p.Y)/64)
+		return Point{x, y}.Fixed()
+	}
+	started := false
+	for _, s := range segs {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			if started {
+				q.Stop(true)
+			}
+			q.Start(pt(s.Args[0]))
+			started = true
+		case sfnt.SegmentOpLineTo:
+			q.Line(pt(s.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			q.QuadBezier(pt(s.Args[0]), pt(s.Args[1]))
+		case sfnt.SegmentOpCubeTo:
+			q.CubeBezier(pt(s.Args[0]), pt(s.Args[1]), pt(s.Args[2]))
+		}
+	}
+	if started {
+		q.Stop(true)
+	}
+	return f.GlyphAdvance(x)
+}
+
+// layout calls fn, if not nil, with each glyph of s, its position along the
+// baseline and its advance width, placing the glyphs by their advance widths
+// and the kerning of the font. It returns the total advance.
+func (f *FontFace) layout(s string, fn func(g sfnt.GlyphIndex, x, adv float64) error) (x float64, err error) {
+	var prev sfnt.GlyphIndex
+	for i, r := range []rune(s) {
+		g, err := f.GlyphIndex(r)
+		if err != nil {
+			return x, err
+		}
+		if i > 0 {
+			k, err := f.Kern(prev, g)
+			if err != nil {
+				return x, err
+			}
+			x += k
+		}
+		adv, err := f.GlyphAdvance(g)
+		if err != nil {
+			return x, err
+		}
+		if fn != nil {
+			if err := fn(g, x, adv); err != nil {
+				return x, err
+			}
+		}
+		x += adv
+		prev = g
+	}
+	return x, nil
+}
+
+// AddString sends the outlines of the glyphs of s to q after mapping them by
+// m. The glyphs are placed along the baseline by their advance widths and the
+// kerning of the font. It returns the total advance in text space.
+func (f *FontFace) AddString(q Adder, s string, m Matrix2D) (float64, error) {
+	return f.layout(s, func(g sfnt.GlyphIndex, x, adv float64) error {
+		_, err := f.AddGlyph(q, g, m.Translate(x, 0))
+		return err
+	})
+}
+
+// Advance returns the total advance of the string s in text space, including
+// kerning, as AddString places it
+func (f *FontFace) Advance(s string) (float64, error) {
+	return f.layout(s, nil)
+}
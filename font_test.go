@@ -0,0 +1,144 @@
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+// Created 2018 by S.R.Wiley
+package rasterx_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	. "github.com/srwiley/rasterx"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+)
+
+func getTestFace(t *testing.T, size float64) *FontFace {
+	f, err := sfnt.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewFontFace(f, size)
+}
+
+func TestFontFace(t *testing.T) {
+	face := getTestFace(t, 40)
+	g, err := face.GlyphIndex('O')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var o Path
+	adv, err := face.AddGlyph(&o, g, Identity.Translate(10, 50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(o.SubpathLengths()); n != 2 {
+		t.Error("O should have two contours", n)
+	}
+	if adv <= 0 || adv > 40 {
+		t.Error("wrong advance", adv)
+	}
+	// The outer contour of the O is above the baseline at y=50 and within
+	// the em box
+	for _, poly := range o.Flatten(0.1, false) {
+		for _, p := range poly.Points {
+			if p.Y > 50+1 || p.Y < 50-40 || p.X < 10 || p.X > 10+adv {
+				t.Fatal("glyph point outside of its box", p)
+			}
+		}
+	}
+	// Drawn twice as large, the outline scales with the matrix
+	var big Path
+	face.AddGlyph(&big, g, Identity.Scale(2, 2))
+	var small Path
+	face.AddGlyph(&small, g, Identity)
+	if l, s := big.Length(), small.Length(); math.Abs(l-2*s) > 0.2 {
+		t.Error("outline does not scale", l, s)
+	}
+
+	var text Path
+	total, err := face.AddString(&text, "AVO", Identity.Translate(10, 50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := face.Advance("AVO"); math.Abs(total-want) > 1e-9 {
+		t.Error("AddString and Advance differ", total, want)
+	}
+	var sum float64
+	for _, r := range "AVO" {
+		gi, _ := face.GlyphIndex(r)
+		a, _ := face.GlyphAdvance(gi)
+		sum += a
+	}
+	ga, _ := face.GlyphIndex('A')
+	gv, _ := face.GlyphIndex('V')
+	kern, _ := face.Kern(ga, gv)
+	if math.Abs(total-(sum+kern)) > 1e-9 {
+		t.Error("string advance should be the sum of advances and kerning", total, sum, kern)
+	}
+	if n := len(text.SubpathLengths()); n != 2+1+2 {
+		t.Error("wrong number of contours in text", n)
+	}
+	if m, n := alphaDiff(fillAlpha(200, 100, text), make([]uint8, 200*100)); m != 255 || n < 100 {
+		t.Error("text did not render", m, n)
+	}
+}
+
+func TestTextPath(t *testing.T) {
+	face := getTestFace(t, 20)
+	var line Path
+	line.Start(ToFixedP(10, 50))
+	line.Line(ToFixedP(400, 50))
+	line.Stop(false)
+
+	// Along a straight line, both methods match plain text on the baseline
+	var plain Path
+	face.AddString(&plain, "Wave", Identity.Translate(30, 50))
+	want := fillAlpha(420, 100, plain)
+	for _, method := range []TextPathMethod{TextPathAlign, TextPathStretch} {
+		tp := &TextPath{Face: face, Path: line, StartOffset: 20, Method: method}
+		p, err := tp.ToPath("Wave")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, n := alphaDiff(fillAlpha(420, 100, p), want); n > 2 {
+			t.Error("text on a line differs from plain text", method, n)
+		}
+	}
+	adv, _ := face.Advance("Wave")
+	tp := &TextPath{Face: face, Path: line, StartOffset: 20 + adv, Anchor: AnchorEnd}
+	p, _ := tp.ToPath("Wave")
+	if _, n := alphaDiff(fillAlpha(420, 100, p), want); n > 2 {
+		t.Error("end anchored text is misplaced", n)
+	}
+
+	// Glyphs past the end of the path are dropped
+	tp = &TextPath{Face: face, Path: line, StartOffset: 380}
+	p, _ = tp.ToPath("Wave")
+	if n := len(p.SubpathLengths()); n == 0 || n >= 4 {
+		t.Error("glyphs beyond the path should be dropped", n)
+	}
+
+	// Around a circle, glyphs stay within a band of the size of the font
+	circle := getCirclePath(150, 150, 100)
+	for _, spacing := range []TextPathSpacing{SpacingExact, SpacingAuto} {
+		for _, method := range []TextPathMethod{TextPathAlign, TextPathStretch} {
+			tp := &TextPath{Face: face, Path: circle, Method: method, Spacing: spacing}
+			p, err := tp.ToPath("Around the circle")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, poly := range p.Flatten(0.1, false) {
+				for _, q := range poly.Points {
+					if d := q.Sub(Point{150, 150}).Len(); d < 100-20 || d > 100+20 {
+						t.Fatal("glyph strays from the circle", method, spacing, d)
+					}
+				}
+			}
+		}
+	}
+	exact, _ := (&TextPath{Face: face, Path: circle}).ToPath("oooooo")
+	auto, _ := (&TextPath{Face: face, Path: circle, Spacing: SpacingAuto}).ToPath("oooooo")
+	if exact.Length() == 0 || reflect.DeepEqual(exact, auto) {
+		t.Error("auto spacing should change the layout on a curve")
+	}
+}
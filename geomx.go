@@ -37,6 +37,67 @@ func Length(v fixed.Point26_6) fixed.Int26_6 {
 	return fixed.Int26_6(math.Sqrt(vx*vx + vy*vy))
 }
 
+// Point is a floating point location or vector in pixel units. It is used
+// where the precision of fixed point values is not sufficient, such as in
+// arc length measurement.
+type Point struct {
+	X, Y float64
+}
+
+// ToPoint converts a fixed point to a Point
+func ToPoint(a fixed.Point26_6) Point {
+	return Point{X: float64(a.X) / 64, Y: float64(a.Y) / 64}
+}
+
+// Fixed converts the Point to a fixed point, rounding to the nearest value
+func (p Point) Fixed() fixed.Point26_6 {
+	return fixed.Point26_6{X: fixed.Int26_6(math.Round(p.X * 64)), Y: fixed.Int26_6(math.Round(p.Y * 64))}
+}
+
+// Add returns the vector p+q
+func (p Point) Add(q Point) Point {
+	return Point{X: p.X + q.X, Y: p.Y + q.Y}
+}
+
+// Sub returns the vector p-q
+func (p Point) Sub(q Point) Point {
+	return Point{X: p.X - q.X, Y: p.Y - q.Y}
+}
+
+// Mul returns the vector p*k
+func (p Point) Mul(k float64) Point {
+	return Point{X: p.X * k, Y: p.Y * k}
+}
+
+// Dot returns the inner product of p and q
+func (p Point) Dot(q Point) float64 {
+	return p.X*q.X + p.Y*q.Y
+}
+
+// Cross returns the z component of the cross product of p and q
+func (p Point) Cross(q Point) float64 {
+	return p.X*q.Y - p.Y*q.X
+}
+
+// Len returns the distance of p from the origin
+func (p Point) Len() float64 {
+	return math.Hypot(p.X, p.Y)
+}
+
+// Unit returns p scaled to unit length, or the zero vector if p is zero
+func (p Point) Unit() Point {
+	l := p.Len()
+	if l == 0 {
+		return Point{}
+	}
+	return Point{X: p.X / l, Y: p.Y / l}
+}
+
+// lerpP returns the point at fraction t of the way from p to q
+func lerpP(t float64, p, q Point) Point {
+	return Point{X: p.X + t*(q.X-p.X), Y: p.Y + t*(q.Y-p.Y)}
+}
+
 //PathCommand is the type for the path command token
 type PathCommand fixed.Int26_6
 
@@ -122,6 +183,27 @@ func (p *Path) Stop(closeLoop bool) {
 	}
 }
 
+// addCmdsTo sends the line and curve commands of p to q. It is used for
+// runs of commands that continue from the current point of q.
+func (p Path) addCmdsTo(q Adder) {
+	for i := 0; i < len(p); {
+		switch PathCommand(p[i]) {
+		case PathLineTo:
+			q.Line(fixed.Point26_6{X: p[i+1], Y: p[i+2]})
+			i += 3
+		case PathQuadTo:
+			q.QuadBezier(fixed.Point26_6{X: p[i+1], Y: p[i+2]}, fixed.Point26_6{X: p[i+3], Y: p[i+4]})
+			i += 5
+		case PathCubicTo:
+			q.CubeBezier(fixed.Point26_6{X: p[i+1], Y: p[i+2]},
+				fixed.Point26_6{X: p[i+3], Y: p[i+4]}, fixed.Point26_6{X: p[i+5], Y: p[i+6]})
+			i += 7
+		default:
+			panic("addCmdsTo: bad path")
+		}
+	}
+}
+
 // AddTo adds the Path p to q.
 func (p Path) AddTo(q Adder) {
 	for i := 0; i < len(p); {
@@ -0,0 +1,113 @@
+// Hairline strokes
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/image/math/fixed"
+)
+
+// hairlineWidth is the width of a hairline stroke, one pixel
+const hairlineWidth = fixed.Int26_6(1 << 6)
+
+// SetHairline sets whether strokes thinner than one pixel are drawn as
+// hairlines. A hairline is stroked one pixel wide, so that it renders as a
+// steady antialiased line at any zoom, and its opacity is scaled by the width
+// set by SetStroke, so that it appears as dark as a line of that width would.
+// A width of zero draws a one pixel line at full opacity. A ScannerGV scales
+// the opacity of its color however it was set, but only while the Draw method
+// of the Stroker or Dasher draws, so fills drawn with the same scanner are not
+// affected. Other scanners only scale the color given to the SetColor method
+// of the Stroker or Dasher. Widths from a width profile are not changed.
+func (r *Stroker) SetHairline(on bool) {
+	r.hairline = on
+	r.applyHairline()
+}
+
+// Hairline returns true if thin strokes are drawn as hairlines
+func (r *Stroker) Hairline() bool {
+	return r.hairline
+}
+
+// opacityScanner is a Scanner that can scale the opacity of the color it
+// draws with, however the color is set
+type opacityScanner interface {
+	setOpacity(k float64)
+}
+
+// opacityImage is an image with the opacity of its colors scaled by k
+type opacityImage struct {
+	image.Image
+	k float64
+}
+
+// At returns the color of the pixel at x, y with its opacity scaled
+func (o opacityImage) At(x, y int) color.Color {
+	return scaleColor(o.Image.At(x, y), o.k)
+}
+
+// ColorModel returns the color model of the scaled colors
+func (o opacityImage) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+// SetColor sets the color of the scanner, scaled by the opacity of a
+// hairline if the stroke is one. clr is a color.Color or a ColorFunc.
+func (r *Stroker) SetColor(clr interface{}) {
+	r.color = clr
+	if _, ok := r.Scanner.(opacityScanner); ok {
+		r.Filler.SetColor(clr) // the scanner scales it
+		return
+	}
+	r.Filler.SetColor(scaleOpacity(clr, r.hairAlpha()))
+}
+
+// hairAlpha returns the opacity by which the color is scaled for a hairline
+func (r *Stroker) hairAlpha() float64 {
+	if !r.hairline || r.width >= hairlineWidth || r.width <= 0 {
+		return 1
+	}
+	return float64(r.width) / float64(hairlineWidth)
+}
+
+// applyHairline sets the half-width and miter limit of the stroke from the
+// width set by SetStroke, widening it to one pixel for a hairline, and
+// updates the opacity of the color unless the scanner scales it in Draw.
+func (r *Stroker) applyHairline() {
+	w := r.width
+	if r.hairline && w < hairlineWidth {
+		w = hairlineWidth
+	}
+	r.u = w / 2
+	r.mLimit = (r.u * r.w.miterRate) >> 6
+	if _, ok := r.Scanner.(opacityScanner); !ok && r.color != nil {
+		r.Filler.SetColor(scaleOpacity(r.color, r.hairAlpha()))
+	}
+}
+
+// scaleOpacity returns the color.Color or ColorFunc clr with its opacity
+// multiplied by k
+func scaleOpacity(clr interface{}, k float64) interface{} {
+	if k >= 1 {
+		return clr
+	}
+	switch c := clr.(type) {
+	case color.Color:
+		return scaleColor(c, k)
+	case ColorFunc:
+		return ColorFunc(func(x, y int) color.Color {
+			return scaleColor(c(x, y), k)
+		})
+	}
+	return clr
+}
+
+// scaleColor returns c with its opacity multiplied by k
+func scaleColor(c color.Color, k float64) color.Color {
+	r, g, b, a := c.RGBA() // alpha premultiplied, so all channels scale
+	return color.RGBA64{uint16(float64(r) * k), uint16(float64(g) * k),
+		uint16(float64(b) * k), uint16(float64(a) * k)}
+}
@@ -0,0 +1,437 @@
+// Intersection of path segments
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	intersectEps   = 1e-7 // distance in pixels at which curves are considered to meet
+	intersectSize  = 1e-3 // size in pixels at which subdivided curves are refined by Newton's method
+	intersectDepth = 48   // maximum depth of curve subdivision
+	maxCurveHits   = 256  // limit on candidate points, reached only by curves that overlap
+	rootTol        = 1e-12
+)
+
+type (
+	// Curve is a path segment: a LineSegment, QuadBez or CubicBez.
+	Curve interface {
+		// Eval returns the point on the curve at parameter t, from 0 to 1
+		Eval(t float64) Point
+		// Deriv returns the derivative of the curve with respect to t
+		Deriv(t float64) Point
+		// Bounds returns the corners of the smallest box containing the curve
+		Bounds() (min, max Point)
+		seg() segment
+	}
+	// LineSegment is a straight line from P0 to P1 in floating point pixel
+	// coordinates.
+	LineSegment struct {
+		P0, P1 Point
+	}
+	// Intersection is a point where two curves meet. T1 and T2 are the
+	// parameters of the point on the first and second curve.
+	Intersection struct {
+		T1, T2 float64
+		P      Point
+	}
+	// PathIntersection is a point where two segments of paths meet. Seg1 and
+	// Seg2 are the indexes of the segments in the slices returned by Segments.
+	PathIntersection struct {
+		Intersection
+		Seg1, Seg2 int
+	}
+	// RayHit is a point where a ray meets a path. S is the parameter along
+	// the ray, which is the distance from the origin in units of the ray
+	// direction, and T is the parameter on segment Seg of the path.
+	RayHit struct {
+		S, T float64
+		Seg  int
+		P    Point
+	}
+)
+
+// Eval returns the point on the line at parameter t
+func (l LineSegment) Eval(t float64) Point {
+	return lerpP(t, l.P0, l.P1)
+}
+
+// Deriv returns the derivative of the line with respect to t
+func (l LineSegment) Deriv(t float64) Point {
+	return l.P1.Sub(l.P0)
+}
+
+// Bounds returns the corners of the smallest box containing the line
+func (l LineSegment) Bounds() (min, max Point) {
+	return Point{X: math.Min(l.P0.X, l.P1.X), Y: math.Min(l.P0.Y, l.P1.Y)},
+		Point{X: math.Max(l.P0.X, l.P1.X), Y: math.Max(l.P0.Y, l.P1.Y)}
+}
+
+func (l LineSegment) seg() segment { return segment{P: [4]Point{l.P0, l.P1}, deg: 1} }
+func (q QuadBez) seg() segment     { return segment{P: [4]Point{q.P0, q.P1, q.P2}, deg: 2} }
+func (c CubicBez) seg() segment    { return segment{P: [4]Point{c.P0, c.P1, c.P2, c.P3}, deg: 3} }
+
+// curve returns the segment as a Curve
+func (s segment) curve() Curve {
+	switch s.deg {
+	case 1:
+		return LineSegment{s.P[0], s.P[1]}
+	case 2:
+		return s.quad()
+	}
+	return s.cubic()
+}
+
+// Segments returns the segments of the path in order. A closed subpath whose
+// last point is not its first ends with a line back to its first point.
+func (p Path) Segments() (segs []Curve) {
+	for _, s := range p.segmentList() {
+		segs = append(segs, s.curve())
+	}
+	return
+}
+
+// hull returns the bounding box of the control points of the segment, which
+// contains the segment
+func (s segment) hull() (min, max Point) {
+	min, max = s.P[0], s.P[0]
+	for _, p := range s.P[1 : s.deg+1] {
+		min = Point{X: math.Min(min.X, p.X), Y: math.Min(min.Y, p.Y)}
+		max = Point{X: math.Max(max.X, p.X), Y: math.Max(max.Y, p.Y)}
+	}
+	return
+}
+
+// degenerate returns true if all of the points of the segment are the same
+func (s segment) degenerate() bool {
+	for _, p := range s.P[1 : s.deg+1] {
+		if p != s.P[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// bernsteinRoots returns the sorted roots in [0, 1] of the polynomial of
+// degree len(b)-1, up to 3, with Bernstein coefficients b. The interval is
+// divided at the roots of the derivative, and each part that changes sign is
+// searched by bisection.
+func bernsteinRoots(b []float64) (ts []float64) {
+	n := len(b) - 1
+	eval := func(t float64) float64 {
+		c := append([]float64(nil), b...)
+		for k := n; k > 0; k-- { // de Casteljau
+			for i := 0; i < k; i++ {
+				c[i] += t * (c[i+1] - c[i])
+			}
+		}
+		return c[0]
+	}
+	cuts := []float64{0}
+	switch n {
+	case 2:
+		cuts = append(cuts, quadRoots(0, b[2]-2*b[1]+b[0], b[1]-b[0])...)
+	case 3:
+		d0, d1, d2 := b[1]-b[0], b[2]-b[1], b[3]-b[2]
+		cuts = append(cuts, quadRoots(d0-2*d1+d2, 2*(d1-d0), d0)...)
+		sort.Float64s(cuts)
+	}
+	cuts = append(cuts, 1)
+	if eval(0) == 0 {
+		ts = append(ts, 0)
+	}
+	for i := 1; i < len(cuts); i++ {
+		lo, hi := cuts[i-1], cuts[i]
+		flo, fhi := eval(lo), eval(hi)
+		if fhi == 0 {
+			ts = append(ts, hi)
+			continue
+		}
+		if flo == 0 || (flo < 0) == (fhi < 0) {
+			continue
+		}
+		for hi-lo > rootTol {
+			mid := (lo + hi) / 2
+			if fm := eval(mid); (fm < 0) == (flo < 0) {
+				lo, flo = mid, fm
+			} else {
+				hi = mid
+			}
+		}
+		ts = append(ts, (lo+hi)/2)
+	}
+	return
+}
+
+// lineHits returns the parameters along the line through a with direction d,
+// and along the segment, of the points where they meet. The line parameter
+// is limited to [sMin, sMax]. Where a line segment runs along the line, the
+// ends of the shared part are returned.
+func lineHits(a, d Point, s segment, sMin, sMax float64) (hits [][2]float64) {
+	dd := d.Dot(d)
+	if dd == 0 {
+		return
+	}
+	f := make([]float64, s.deg+1)
+	for i := range f {
+		f[i] = d.Cross(s.P[i].Sub(a)) / math.Sqrt(dd) // signed distance from the line
+	}
+	var ts []float64
+	if s.deg == 1 && math.Abs(f[0]) < intersectEps && math.Abs(f[1]) < intersectEps {
+		// Collinear; the ends of the overlap are the ends of either that are in the other
+		ts = append(ts, 0, 1)
+		s0, s1 := s.P[0].Sub(a).Dot(d)/dd, s.P[1].Sub(a).Dot(d)/dd
+		if s0 != s1 {
+			for _, sl := range []float64{sMin, sMax} {
+				if u := (sl - s0) / (s1 - s0); u > 0 && u < 1 {
+					ts = append(ts, u)
+				}
+			}
+		}
+	} else {
+		ts = bernsteinRoots(f)
+	}
+	const eps = 1e-9
+	for _, t := range ts {
+		sl := s.eval(t).Sub(a).Dot(d) / dd
+		if sl >= sMin-eps && sl <= sMax+eps {
+			hits = append(hits, [2]float64{math.Max(sMin, math.Min(sMax, sl)), t})
+		}
+	}
+	return
+}
+
+// intersectSegments returns the points where the segments a and b meet
+func intersectSegments(a, b segment) (hits []Intersection) {
+	if a.degenerate() || b.degenerate() {
+		return
+	}
+	switch {
+	case a.deg == 1:
+		for _, h := range lineHits(a.P[0], a.P[1].Sub(a.P[0]), b, 0, 1) {
+			hits = append(hits, Intersection{T1: h[0], T2: h[1], P: b.eval(h[1])})
+		}
+	case b.deg == 1:
+		for _, h := range lineHits(b.P[0], b.P[1].Sub(b.P[0]), a, 0, 1) {
+			hits = append(hits, Intersection{T1: h[1], T2: h[0], P: a.eval(h[1])})
+		}
+	default:
+		intersectCurves(a, 0, 1, b, 0, 1, 0, &hits)
+	}
+	return dedupeHits(hits)
+}
+
+// intersectCurves finds the meeting points of a and b by subdividing them
+// until their control point boxes are small, then refining the parameters
+// with Newton's method. a and b are the parts of the original curves between
+// the parameters ta0 and ta1, and tb0 and tb1.
+func intersectCurves(a segment, ta0, ta1 float64, b segment, tb0, tb1 float64, depth int, hits *[]Intersection) {
+	amin, amax := a.hull()
+	bmin, bmax := b.hull()
+	if len(*hits) >= maxCurveHits || amin.X > bmax.X+intersectEps || bmin.X > amax.X+intersectEps ||
+		amin.Y > bmax.Y+intersectEps || bmin.Y > amax.Y+intersectEps {
+		return
+	}
+	asize := math.Max(amax.X-amin.X, amax.Y-amin.Y)
+	bsize := math.Max(bmax.X-bmin.X, bmax.Y-bmin.Y)
+	if (asize < intersectSize && bsize < intersectSize) || depth >= intersectDepth {
+		if h, ok := refineHit(a, b, (ta0+ta1)/2, (tb0+tb1)/2, ta0, ta1, tb0, tb1); ok {
+			*hits = append(*hits, h)
+		}
+		return
+	}
+	if asize >= bsize {
+		a0, a1 := a.split(0.5)
+		tm := (ta0 + ta1) / 2
+		intersectCurves(a0, ta0, tm, b, tb0, tb1, depth+1, hits)
+		intersectCurves(a1, tm, ta1, b, tb0, tb1, depth+1, hits)
+		return
+	}
+	b0, b1 := b.split(0.5)
+	tm := (tb0 + tb1) / 2
+	intersectCurves(a, ta0, ta1, b0, tb0, tm, depth+1, hits)
+	intersectCurves(a, ta0, ta1, b1, tm, tb1, depth+1, hits)
+}
+
+// refineHit uses Newton's method to find the parameters, near t and u, at
+// which the parts of the original curves of a and b within the given
+// parameter ranges meet. The parts are reparameterized to their ranges.
+func refineHit(a, b segment, t, u, ta0, ta1, tb0, tb1 float64) (Intersection, bool) {
+	lt, lu := (t-ta0)/(ta1-ta0), (u-tb0)/(tb1-tb0)
+	for i := 0; i < 16; i++ {
+		f := a.eval(lt).Sub(b.eval(lu))
+		if f.Len() < intersectEps {
+			break
+		}
+		da, db := a.deriv(lt), b.deriv(lu)
+		det := db.Cross(da)
+		if det == 0 {
+			break
+		}
+		// Solve da*dt - db*du = -f
+		dt, du := db.Cross(f)/det, da.Cross(f)/det
+		lt, lu = math.Max(0, math.Min(1, lt-dt)), math.Max(0, math.Min(1, lu-du))
+	}
+	pa, pb := a.eval(lt), b.eval(lu)
+	if pa.Sub(pb).Len() > intersectSize*1e-2 {
+		return Intersection{}, false
+	}
+	return Intersection{T1: ta0 + lt*(ta1-ta0), T2: tb0 + lu*(tb1-tb0), P: lerpP(0.5, pa, pb)}, true
+}
+
+// dedupeHits sorts the hits by T1 and removes hits at the same point as the
+// one before them
+func dedupeHits(hits []Intersection) []Intersection {
+	sort.Slice(hits, func(i, j int) bool { return hits[i].T1 < hits[j].T1 })
+	out := hits[:0]
+	for _, h := range hits {
+		dup := false
+		for _, o := range out {
+			if math.Abs(o.T1-h.T1) < 1e-6 && math.Abs(o.T2-h.T2) < 1e-6 ||
+				o.P.Sub(h.P).Len() < intersectSize*1e-2 {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// selfIntersections returns the points where a cubic segment crosses itself.
+// Lines and quadratic curves never do. The cubic is divided at its extrema
+// into parts that are monotonic in x and y, which cannot cross themselves,
+// and each pair of parts that are not neighbors is intersected.
+func (s segment) selfIntersections() (hits []Intersection) {
+	if s.deg != 3 {
+		return
+	}
+	cuts := append([]float64{0}, s.cubic().Extrema()...)
+	cuts = append(cuts, 1)
+	for i := 1; i < len(cuts); i++ {
+		for j := i + 1; j < len(cuts); j++ {
+			pi, pj := s.sub(cuts[i-1], cuts[i]), s.sub(cuts[j-1], cuts[j])
+			for _, h := range intersectSegments(pi, pj) {
+				h.T1 = cuts[i-1] + h.T1*(cuts[i]-cuts[i-1])
+				h.T2 = cuts[j-1] + h.T2*(cuts[j]-cuts[j-1])
+				if h.T2-h.T1 > 1e-6 && !(j == i+1 && h.P.Sub(s.eval(cuts[i])).Len() < intersectSize) {
+					hits = append(hits, h)
+				}
+			}
+		}
+	}
+	return dedupeHits(hits)
+}
+
+// Intersect returns the points where the curves a and b meet, sorted by
+// their parameter on a. Where two lines overlap, the ends of the overlap
+// are returned.
+func Intersect(a, b Curve) []Intersection {
+	return intersectSegments(a.seg(), b.seg())
+}
+
+// SelfIntersections returns the points where the curve crosses itself. Only
+// a cubic curve with a loop crosses itself. T1 is less than T2 for each
+// point.
+func SelfIntersections(c Curve) []Intersection {
+	return c.seg().selfIntersections()
+}
+
+// Intersections returns the points where the segments of p meet the segments
+// of q. Seg1 is the index of a segment of p and Seg2 of q.
+func (p Path) Intersections(q Path) (hits []PathIntersection) {
+	ps, qs := p.segmentList(), q.segmentList()
+	for i, a := range ps {
+		amin, amax := a.hull()
+		for j, b := range qs {
+			bmin, bmax := b.hull()
+			if amin.X > bmax.X || bmin.X > amax.X || amin.Y > bmax.Y || bmin.Y > amax.Y {
+				continue
+			}
+			for _, h := range intersectSegments(a, b) {
+				hits = append(hits, PathIntersection{Intersection: h, Seg1: i, Seg2: j})
+			}
+		}
+	}
+	return
+}
+
+// SelfIntersections returns the points where the segments of the path meet
+// each other, other than where neighboring segments join, and where cubic
+// segments cross themselves. Seg1 is not greater than Seg2 for each point.
+func (p Path) SelfIntersections() (hits []PathIntersection) {
+	var segs []segment
+	var next []int // index of the segment that follows each segment in its subpath
+	for _, sp := range p.subpaths() {
+		first := len(segs)
+		for k, s := range sp.segs {
+			segs = append(segs, s)
+			nx := len(segs)
+			if k == len(sp.segs)-1 {
+				nx = -1
+				if sp.closed {
+					nx = first
+				}
+			}
+			next = append(next, nx)
+		}
+	}
+	for i, a := range segs {
+		for _, h := range a.selfIntersections() {
+			hits = append(hits, PathIntersection{Intersection: h, Seg1: i, Seg2: i})
+		}
+		amin, amax := a.hull()
+		for j := i + 1; j < len(segs); j++ {
+			b := segs[j]
+			bmin, bmax := b.hull()
+			if amin.X > bmax.X || bmin.X > amax.X || amin.Y > bmax.Y || bmin.Y > amax.Y {
+				continue
+			}
+			for _, h := range intersectSegments(a, b) {
+				// Skip the joins of neighboring segments
+				if (next[i] == j && h.P.Sub(a.end()).Len() < intersectSize) ||
+					(next[j] == i && h.P.Sub(a.P[0]).Len() < intersectSize) {
+					continue
+				}
+				hits = append(hits, PathIntersection{Intersection: h, Seg1: i, Seg2: j})
+			}
+		}
+	}
+	return
+}
+
+// RayIntersections returns the points where the ray from origin in the
+// direction dir meets the path, sorted by distance from the origin.
+// A ray that passes through a join of two segments gives one point.
+func (p Path) RayIntersections(origin, dir Point) (hits []RayHit) {
+	for i, s := range p.segmentList() {
+		if s.degenerate() {
+			continue
+		}
+		for _, h := range lineHits(origin, dir, s, 0, math.Inf(1)) {
+			hits = append(hits, RayHit{S: h[0], T: h[1], Seg: i, P: s.eval(h[1])})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].S < hits[j].S })
+	// Remove the repeats found at the joins of segments
+	out := hits[:0]
+	for _, h := range hits {
+		if len(out) == 0 || h.P.Sub(out[len(out)-1].P).Len() >= intersectSize {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// segmentList returns the segments of the path in the order of Segments
+func (p Path) segmentList() (segs []segment) {
+	for _, sp := range p.subpaths() {
+		segs = append(segs, sp.segs...)
+	}
+	return
+}
@@ -0,0 +1,97 @@
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+// Created 2018 by S.R.Wiley
+package rasterx_test
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/srwiley/rasterx"
+)
+
+func TestIntersect(t *testing.T) {
+	near := func(p, q Point) bool { return p.Sub(q).Len() < 1e-5 }
+	l1 := LineSegment{Point{0, 0}, Point{100, 100}}
+	l2 := LineSegment{Point{0, 100}, Point{100, 0}}
+	if hs := Intersect(l1, l2); len(hs) != 1 || !near(hs[0].P, Point{50, 50}) ||
+		math.Abs(hs[0].T1-0.5) > 1e-9 || math.Abs(hs[0].T2-0.5) > 1e-9 {
+		t.Error("wrong line intersection", hs)
+	}
+	if hs := Intersect(l1, LineSegment{Point{50, 50}, Point{150, 150}}); len(hs) != 2 {
+		t.Error("overlapping lines should give the ends of the overlap", hs)
+	}
+	// Cubics that cross three times
+	c1 := CubicBez{Point{0, 0}, Point{100, 300}, Point{200, -200}, Point{300, 100}}
+	c2 := CubicBez{Point{0, 50}, Point{100, 50}, Point{200, 50}, Point{300, 50}}
+	hs := Intersect(c1, c2)
+	if len(hs) != 3 {
+		t.Fatal("wrong number of cubic hits", hs)
+	}
+	for _, h := range hs {
+		if !near(c1.Eval(h.T1), h.P) || !near(c2.Eval(h.T2), h.P) || math.Abs(h.P.Y-50) > 1e-5 {
+			t.Error("cubic hit not on both curves", h)
+		}
+	}
+	// The same crossings are found with the line
+	if hl := Intersect(LineSegment{Point{0, 50}, Point{300, 50}}, c1); len(hl) != 3 {
+		t.Error("wrong number of line hits", hl)
+	} else {
+		for i := range hl {
+			if !near(hl[i].P, hs[i].P) || math.Abs(hl[i].T2-hs[i].T1) > 1e-6 {
+				t.Error("line and cubic hits differ", hl[i], hs[i])
+			}
+		}
+	}
+	// Coincident curves meet everywhere; a bounded number of points is returned
+	if hs := Intersect(c1, c1); len(hs) == 0 {
+		t.Error("coincident curves should meet")
+	}
+	q := QuadBez{Point{0, 100}, Point{150, -100}, Point{300, 100}}
+	for _, h := range Intersect(q, c1) {
+		if !near(q.Eval(h.T1), h.P) || !near(c1.Eval(h.T2), h.P) {
+			t.Error("quad hit not on both curves", h)
+		}
+	}
+	loop := CubicBez{Point{0, 0}, Point{300, 100}, Point{-100, 100}, Point{200, 0}}
+	if hs := SelfIntersections(loop); len(hs) != 1 || math.Abs(hs[0].P.X-100) > 1e-5 ||
+		!near(loop.Eval(hs[0].T1), loop.Eval(hs[0].T2)) || hs[0].T2-hs[0].T1 < 0.1 {
+		t.Error("wrong cubic self intersection", hs)
+	}
+	if hs := SelfIntersections(c1); len(hs) != 0 {
+		t.Error("cubic without a loop should not cross itself", hs)
+	}
+
+	// Paths
+	sq := getSquarePath(10, 10, 110, 110)
+	circle := getCirclePath(110, 60, 30)
+	if hs := sq.Intersections(circle); len(hs) != 2 {
+		t.Error("square and circle should meet twice", hs)
+	} else {
+		segs, csegs := sq.Segments(), circle.Segments()
+		for _, h := range hs {
+			if math.Abs(h.P.X-110) > 1e-5 || !near(segs[h.Seg1].Eval(h.T1), h.P) || !near(csegs[h.Seg2].Eval(h.T2), h.P) {
+				t.Error("wrong path intersection", h)
+			}
+		}
+	}
+	if hs := sq.SelfIntersections(); len(hs) != 0 {
+		t.Error("square should not cross itself", hs)
+	}
+	var bowtie Path
+	bowtie.Start(ToFixedP(0, 0))
+	bowtie.Line(ToFixedP(100, 100))
+	bowtie.Line(ToFixedP(100, 0))
+	bowtie.Line(ToFixedP(0, 100))
+	bowtie.Stop(true)
+	if hs := bowtie.SelfIntersections(); len(hs) != 1 || !near(hs[0].P, Point{50, 50}) || hs[0].Seg1 != 0 || hs[0].Seg2 != 2 {
+		t.Error("wrong bow tie self intersection", hs)
+	}
+
+	rh := circle.RayIntersections(Point{0, 60}, Point{1, 0})
+	if len(rh) != 2 || math.Abs(rh[0].S-80) > 0.01 || math.Abs(rh[1].S-140) > 0.01 {
+		t.Error("wrong ray hits", rh)
+	}
+	if rh := sq.RayIntersections(Point{60, 60}, Point{0, -2}); len(rh) != 1 || !near(rh[0].P, Point{60, 10}) || math.Abs(rh[0].S-25) > 1e-9 {
+		t.Error("ray from inside should hit once", rh)
+	}
+}
@@ -0,0 +1,141 @@
+// Markers placed at the vertices of paths
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"math"
+)
+
+type (
+	// MarkerUnits determines the scale of a marker, as the SVG markerUnits
+	// attribute does
+	MarkerUnits uint8
+
+	// MarkerOrient determines the rotation of a marker, as the SVG orient
+	// attribute does
+	MarkerOrient uint8
+
+	// Marker is a shape drawn at vertices of a path, like the SVG marker
+	// element. Path is drawn with the point RefX, RefY on the vertex.
+	Marker struct {
+		Path       Path
+		RefX, RefY float64
+		Units      MarkerUnits
+		Orient     MarkerOrient
+		Angle      float64 // rotation in radians for OrientAngle
+	}
+)
+
+// MarkerUnits constants
+const (
+	// MarkerStrokeWidth scales the marker by the stroke width of the path
+	MarkerStrokeWidth MarkerUnits = iota
+	// MarkerUserSpace draws the marker in the units of the path
+	MarkerUserSpace
+)
+
+// MarkerOrient constants
+const (
+	// OrientAngle rotates the marker by its Angle
+	OrientAngle MarkerOrient = iota
+	// OrientAuto rotates the x axis of the marker to the direction of the
+	// path at the vertex, which is the bisector of the directions in and
+	// out of the vertex where it joins two segments
+	OrientAuto
+	// OrientAutoStartReverse is OrientAuto, except that a marker at the
+	// start of the path points backwards, so that the same arrowhead can be
+	// used at both ends
+	OrientAutoStartReverse
+)
+
+// MarkerVertices returns the vertices of p at which SVG places markers: the
+// start of each subpath and the end of each segment, including the segment
+// that closes a closed subpath. Each is a C2Point with the point P, the
+// trailing tangent TTan of the segment into it and the leading tangent LTan
+// of the segment out of it, as the Stroker computes them. TTan is zero at the
+// start of an open subpath and LTan at its end. At the start and end of a
+// closed subpath, the tangents are those of its last and first segments.
+func (p Path) MarkerVertices() (vs []C2Point) {
+	for _, sp := range p.subpaths() {
+		n := len(sp.segs)
+		if n == 0 {
+			vs = append(vs, C2Point{P: sp.start.Fixed()})
+			continue
+		}
+		first := C2Point{P: sp.start.Fixed(), LTan: sp.segs[0].tangent(0).Fixed()}
+		if sp.closed {
+			first.TTan = sp.segs[n-1].tangent(1).Fixed()
+		}
+		vs = append(vs, first)
+		for i, s := range sp.segs {
+			v := C2Point{P: s.end().Fixed(), TTan: s.tangent(1).Fixed()}
+			switch {
+			case i < n-1:
+				v.LTan = sp.segs[i+1].tangent(0).Fixed()
+			case sp.closed:
+				v.LTan = first.LTan
+			}
+			vs = append(vs, v)
+		}
+	}
+	return
+}
+
+// markerDirection returns the angle of the direction of the path at v, the
+// bisector of its tangents
+func markerDirection(v C2Point) float64 {
+	d := ToPoint(v.TTan).Unit().Add(ToPoint(v.LTan).Unit())
+	if d.Len() < 1e-9 { // a cusp, or no tangents; use the way in
+		d = ToPoint(v.TTan)
+		if d.X == 0 && d.Y == 0 {
+			d = ToPoint(v.LTan)
+		}
+	}
+	return math.Atan2(d.Y, d.X)
+}
+
+// Matrix returns the transform from the units of the marker to those of
+// the path for the marker at the vertex v of a path stroked with the given
+// width. isStart is true for the vertex at the start of the path.
+func (mk *Marker) Matrix(v C2Point, isStart bool, strokeWidth float64) Matrix2D {
+	angle := mk.Angle
+	switch mk.Orient {
+	case OrientAuto:
+		angle = markerDirection(v)
+	case OrientAutoStartReverse:
+		angle = markerDirection(v)
+		if isStart {
+			angle += math.Pi
+		}
+	}
+	scale := 1.0
+	if mk.Units == MarkerStrokeWidth {
+		scale = strokeWidth
+	}
+	pt := ToPoint(v.P)
+	return Identity.Translate(pt.X, pt.Y).Rotate(angle).Scale(scale, scale).Translate(-mk.RefX, -mk.RefY)
+}
+
+// AddMarkers sends markers placed at the vertices of p to q, after mapping
+// them by m, which is the transform of p. The start marker is placed on the
+// first vertex, the end marker on the last, and the mid marker on all of the
+// others. Any of the markers may be nil. strokeWidth is the width of the
+// stroke of p in its units. q may be a Filler, Stroker or Dasher to render
+// the markers, or a Path to keep them.
+func (p Path) AddMarkers(q Adder, start, mid, end *Marker, strokeWidth float64, m Matrix2D) {
+	vs := p.MarkerVertices()
+	for i, v := range vs {
+		mk := mid
+		switch {
+		case i == 0:
+			mk = start
+		case i == len(vs)-1:
+			mk = end
+		}
+		if mk == nil {
+			continue
+		}
+		mk.Path.AddTo(&MatrixAdder{Adder: q, M: m.Mult(mk.Matrix(v, i == 0, strokeWidth))})
+	}
+}
@@ -104,6 +104,14 @@ func (a Matrix2D) TransformVector(x1, y1 float64) (x2, y2 float64) {
 	return
 }
 
+// MaxScale returns the largest factor by which the matrix stretches a vector,
+// which is the largest singular value of its linear part.
+func (a Matrix2D) MaxScale() float64 {
+	p := (a.A*a.A + a.B*a.B + a.C*a.C + a.D*a.D) / 2
+	det := a.A*a.D - a.B*a.C
+	return math.Sqrt(p + math.Sqrt(math.Max(p*p-det*det, 0)))
+}
+
 //Scale matrix in x and y dimensions
 func (a Matrix2D) Scale(x, y float64) Matrix2D {
 	return a.Mult(Matrix2D{
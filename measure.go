@@ -0,0 +1,431 @@
+// Arc length measurement of paths
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"math"
+
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	arcLengthTol   = 1e-6 // absolute error allowed in the adaptive length integration, in pixels
+	arcLengthDepth = 18   // maximum recursion depth of the adaptive integration
+)
+
+// Abscissae and weights of 8 point Gauss-Legendre quadrature on [-1, 1]
+var (
+	glX = [8]float64{-0.9602898564975363, -0.7966664774136267, -0.5255324099163290, -0.1834346424956498,
+		0.1834346424956498, 0.5255324099163290, 0.7966664774136267, 0.9602898564975363}
+	glW = [8]float64{0.1012285362903763, 0.2223810344533745, 0.3137066458778873, 0.3626837833783620,
+		0.3626837833783620, 0.3137066458778873, 0.2223810344533745, 0.1012285362903763}
+)
+
+// segment is a line, quadratic or cubic bezier path segment in floating point
+// coordinates. P[0] is the start point, and P[deg] is the end point.
+type segment struct {
+	P   [4]Point
+	deg int
+}
+
+// subpath is a run of segments that begins with a move to
+type subpath struct {
+	start  Point
+	segs   []segment
+	closed bool
+}
+
+// end returns the end point of the segment
+func (s segment) end() Point {
+	return s.P[s.deg]
+}
+
+// quad returns the segment as a QuadBez
+func (s segment) quad() QuadBez {
+	return QuadBez{s.P[0], s.P[1], s.P[2]}
+}
+
+// cubic returns the segment as a CubicBez
+func (s segment) cubic() CubicBez {
+	return CubicBez{s.P[0], s.P[1], s.P[2], s.P[3]}
+}
+
+// eval returns the point on the segment at parameter t
+func (s segment) eval(t float64) Point {
+	switch s.deg {
+	case 1:
+		return lerpP(t, s.P[0], s.P[1])
+	case 2:
+		return s.quad().Eval(t)
+	}
+	return s.cubic().Eval(t)
+}
+
+// deriv returns the derivative of the segment with respect to t
+func (s segment) deriv(t float64) Point {
+	switch s.deg {
+	case 1:
+		return s.P[1].Sub(s.P[0])
+	case 2:
+		return s.quad().Deriv(t)
+	}
+	return s.cubic().Deriv(t)
+}
+
+// tangent returns the direction of the segment at t. Unlike deriv, it
+// falls back on the neighboring control points when the derivative vanishes
+// at an end point, which happens when control points coincide.
+func (s segment) tangent(t float64) Point {
+	d := s.deriv(t)
+	if d.X != 0 || d.Y != 0 {
+		return d
+	}
+	switch {
+	case t <= 0:
+		for i := 1; i <= s.deg; i++ {
+			if s.P[i] != s.P[0] {
+				return s.P[i].Sub(s.P[0])
+			}
+		}
+	case t >= 1:
+		for i := s.deg - 1; i >= 0; i-- {
+			if s.P[i] != s.P[s.deg] {
+				return s.P[s.deg].Sub(s.P[i])
+			}
+		}
+	}
+	return s.P[s.deg].Sub(s.P[0])
+}
+
+// split divides the segment at t using de Casteljau's algorithm
+func (s segment) split(t float64) (a, b segment) {
+	a.deg, b.deg = s.deg, s.deg
+	switch s.deg {
+	case 1:
+		m := lerpP(t, s.P[0], s.P[1])
+		a.P[0], a.P[1] = s.P[0], m
+		b.P[0], b.P[1] = m, s.P[1]
+	case 2:
+		qa, qb := s.quad().Split(t)
+		a.P[0], a.P[1], a.P[2] = qa.P0, qa.P1, qa.P2
+		b.P[0], b.P[1], b.P[2] = qb.P0, qb.P1, qb.P2
+	default:
+		ca, cb := s.cubic().Split(t)
+		a.P = [4]Point{ca.P0, ca.P1, ca.P2, ca.P3}
+		b.P = [4]Point{cb.P0, cb.P1, cb.P2, cb.P3}
+	}
+	return
+}
+
+// sub returns the portion of the segment between t0 and t1
+func (s segment) sub(t0, t1 float64) segment {
+	if t0 > 0 {
+		_, s = s.split(t0)
+		if t0 < 1 {
+			t1 = (t1 - t0) / (1 - t0)
+		}
+	}
+	if t1 < 1 {
+		s, _ = s.split(t1)
+	}
+	return s
+}
+
+// reverse returns the segment traversed in the opposite direction
+func (s segment) reverse() (r segment) {
+	r.deg = s.deg
+	for i := 0; i <= s.deg; i++ {
+		r.P[i] = s.P[s.deg-i]
+	}
+	return
+}
+
+// addTo sends the segment to the Adder. The start point is assumed to be the
+// current point of q.
+func (s segment) addTo(q Adder) {
+	switch s.deg {
+	case 1:
+		q.Line(s.P[1].Fixed())
+	case 2:
+		q.QuadBezier(s.P[1].Fixed(), s.P[2].Fixed())
+	default:
+		q.CubeBezier(s.P[1].Fixed(), s.P[2].Fixed(), s.P[3].Fixed())
+	}
+}
+
+// flattenCount returns the number of lines needed to approximate the segment
+// within tol, using Wang's formula. The bound depends only on the second
+// differences of the control points, so the count is found without
+// subdivision.
+func (s segment) flattenCount(tol float64) int {
+	if s.deg == 1 || tol <= 0 {
+		return 1
+	}
+	var m float64 // max norm of the second differences
+	for i := 0; i+2 <= s.deg; i++ {
+		m = math.Max(m, s.P[i].Sub(s.P[i+1].Mul(2)).Add(s.P[i+2]).Len())
+	}
+	n := math.Ceil(math.Sqrt(float64(s.deg*(s.deg-1)) * m / (8 * tol)))
+	if n < 1 {
+		return 1
+	}
+	return int(n)
+}
+
+// gaussLength integrates the speed of the segment from t0 to t1
+func (s segment) gaussLength(t0, t1 float64) (l float64) {
+	h, m := (t1-t0)/2, (t1+t0)/2
+	for i, x := range glX {
+		l += glW[i] * s.deriv(m+h*x).Len()
+	}
+	return l * h
+}
+
+// adaptiveLength recursively subdivides the interval until the
+// quadrature of the whole and the halves agree.
+func (s segment) adaptiveLength(t0, t1, whole float64, depth int) float64 {
+	m := (t0 + t1) / 2
+	left, right := s.gaussLength(t0, m), s.gaussLength(m, t1)
+	if depth == 0 || math.Abs(left+right-whole) < arcLengthTol {
+		return left + right
+	}
+	return s.adaptiveLength(t0, m, left, depth-1) + s.adaptiveLength(m, t1, right, depth-1)
+}
+
+// lengthTo returns the arc length of the segment from 0 to t
+func (s segment) lengthTo(t float64) float64 {
+	if t <= 0 {
+		return 0
+	}
+	if s.deg == 1 {
+		return s.P[1].Sub(s.P[0]).Len() * t
+	}
+	return s.adaptiveLength(0, t, s.gaussLength(0, t), arcLengthDepth)
+}
+
+// length returns the arc length of the segment
+func (s segment) length() float64 {
+	return s.lengthTo(1)
+}
+
+// paramAt returns the parameter t at which the arc length of the segment
+// from 0 equals l. segLen is the length of the whole segment.
+func (s segment) paramAt(l, segLen float64) float64 {
+	switch {
+	case l <= 0 || segLen <= 0:
+		return 0
+	case l >= segLen:
+		return 1
+	case s.deg == 1:
+		return l / segLen
+	}
+	// Newton's method, safeguarded by bisection
+	lo, hi := 0.0, 1.0
+	t := l / segLen
+	for i := 0; i < 32; i++ {
+		f := s.lengthTo(t) - l
+		if math.Abs(f) < arcLengthTol {
+			break
+		}
+		if f > 0 {
+			hi = t
+		} else {
+			lo = t
+		}
+		d := s.deriv(t).Len()
+		nt := t - f/d
+		if d == 0 || nt <= lo || nt >= hi {
+			nt = (lo + hi) / 2
+		}
+		t = nt
+	}
+	return t
+}
+
+// subpaths breaks the path into subpaths of floating point segments. A
+// closed subpath whose last point is not its first is given an explicit
+// closing line segment.
+func (p Path) subpaths() (sps []subpath) {
+	var cur Point
+	var sp *subpath
+	begin := func(a Point) {
+		sps = append(sps, subpath{start: a})
+		sp = &sps[len(sps)-1]
+		cur = a
+	}
+	for i := 0; i < len(p); {
+		switch PathCommand(p[i]) {
+		case PathMoveTo:
+			begin(ToPoint(fixed.Point26_6{X: p[i+1], Y: p[i+2]}))
+			i += 3
+			continue
+		case PathClose:
+			if sp != nil && !sp.closed {
+				if cur != sp.start {
+					sp.segs = append(sp.segs, segment{P: [4]Point{cur, sp.start}, deg: 1})
+				}
+				sp.closed = true
+				cur = sp.start
+			}
+			i++
+			continue
+		}
+		if sp == nil || sp.closed { // implicit move to the current point
+			begin(cur)
+		}
+		s := segment{P: [4]Point{cur}}
+		switch PathCommand(p[i]) {
+		case PathLineTo:
+			s.deg = 1
+		case PathQuadTo:
+			s.deg = 2
+		case PathCubicTo:
+			s.deg = 3
+		default:
+			panic("subpaths: bad path")
+		}
+		for j := 1; j <= s.deg; j++ {
+			s.P[j] = ToPoint(fixed.Point26_6{X: p[i+2*j-1], Y: p[i+2*j]})
+		}
+		sp.segs = append(sp.segs, s)
+		cur = s.end()
+		i += 1 + 2*s.deg
+	}
+	return
+}
+
+// addTo sends the subpath to the Adder q. A final line segment that ends at
+// the start point of a closed subpath is left for the close to draw, and lines
+// that round to zero length are skipped.
+func (sp subpath) addTo(q Adder) {
+	cur := sp.start.Fixed()
+	q.Start(cur)
+	segs := sp.segs
+	if n := len(segs); sp.closed && n > 0 && segs[n-1].deg == 1 && segs[n-1].end().Fixed() == cur {
+		segs = segs[:n-1]
+	}
+	for _, s := range segs {
+		end := s.end().Fixed()
+		if s.deg == 1 && end == cur {
+			continue
+		}
+		s.addTo(q)
+		cur = end
+	}
+	q.Stop(sp.closed)
+}
+
+// length returns the arc length of the subpath
+func (sp subpath) length() (l float64) {
+	for _, s := range sp.segs {
+		l += s.length()
+	}
+	return
+}
+
+// PathMeasure caches the segment lengths of a path so that repeated
+// queries of points and tangents at given lengths are efficient.
+// Lengths are in pixels and are accumulated across subpaths, not counting
+// the gaps between them, as with the SVG getPointAtLength method.
+type PathMeasure struct {
+	sps     []subpath
+	segLens [][]float64
+	spLens  []float64
+	total   float64
+}
+
+// NewPathMeasure returns a PathMeasure for the path p
+func NewPathMeasure(p Path) *PathMeasure {
+	m := &PathMeasure{sps: p.subpaths()}
+	for _, sp := range m.sps {
+		lens := make([]float64, len(sp.segs))
+		var spLen float64
+		for j, s := range sp.segs {
+			lens[j] = s.length()
+			spLen += lens[j]
+		}
+		m.segLens = append(m.segLens, lens)
+		m.spLens = append(m.spLens, spLen)
+		m.total += spLen
+	}
+	return m
+}
+
+// Length returns the total arc length of the path
+func (m *PathMeasure) Length() float64 {
+	return m.total
+}
+
+// SubpathLengths returns the arc length of each subpath
+func (m *PathMeasure) SubpathLengths() []float64 {
+	return append([]float64(nil), m.spLens...)
+}
+
+// locate finds the subpath, segment and segment parameter at length l,
+// which is clamped to the length of the path. ok is false if the path
+// has no segments.
+func (m *PathMeasure) locate(l float64) (sp, seg int, t float64, ok bool) {
+	if l < 0 {
+		l = 0
+	}
+	lastSp, lastSeg := -1, -1
+	for i, lens := range m.segLens {
+		for j, sl := range lens {
+			if l <= sl && sl > 0 {
+				return i, j, m.sps[i].segs[j].paramAt(l, sl), true
+			}
+			l -= sl
+			lastSp, lastSeg = i, j
+		}
+	}
+	if lastSp < 0 {
+		return 0, 0, 0, false
+	}
+	return lastSp, lastSeg, 1, true
+}
+
+// PointAtLength returns the point at arc length l along the path.
+// l is clamped to the range of the path length.
+func (m *PathMeasure) PointAtLength(l float64) Point {
+	i, j, t, ok := m.locate(l)
+	if !ok {
+		if len(m.sps) > 0 {
+			return m.sps[0].start
+		}
+		return Point{}
+	}
+	return m.sps[i].segs[j].eval(t)
+}
+
+// TangentAtLength returns the unit tangent vector at arc length l along the path.
+// The zero vector is returned if the path has no extent.
+func (m *PathMeasure) TangentAtLength(l float64) Point {
+	i, j, t, ok := m.locate(l)
+	if !ok {
+		return Point{}
+	}
+	return m.sps[i].segs[j].tangent(t).Unit()
+}
+
+// Length returns the total arc length of the path in pixels
+func (p Path) Length() float64 {
+	return NewPathMeasure(p).Length()
+}
+
+// SubpathLengths returns the arc length of each subpath of p in pixels
+func (p Path) SubpathLengths() []float64 {
+	return NewPathMeasure(p).SubpathLengths()
+}
+
+// PointAtLength returns the point at arc length l along the path.
+// Use a PathMeasure when making repeated queries on the same path.
+func (p Path) PointAtLength(l float64) Point {
+	return NewPathMeasure(p).PointAtLength(l)
+}
+
+// TangentAtLength returns the unit tangent at arc length l along the path.
+// Use a PathMeasure when making repeated queries on the same path.
+func (p Path) TangentAtLength(l float64) Point {
+	return NewPathMeasure(p).TangentAtLength(l)
+}
@@ -0,0 +1,169 @@
+// Interpolation between paths
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"math"
+)
+
+// Morph interpolates between two paths. The paths are prepared once by
+// NewMorph so that each frame of an animation only blends control points.
+type Morph struct {
+	a, b   []subpath // matched subpaths of cubic segments
+	closed [][2]bool // whether each subpath of a and of b is closed
+}
+
+// toCubic returns the segment as a cubic segment that traces the same path
+func (s segment) toCubic() segment {
+	switch s.deg {
+	case 1:
+		return segment{P: [4]Point{s.P[0], lerpP(1.0/3, s.P[0], s.P[1]), lerpP(2.0/3, s.P[0], s.P[1]), s.P[1]}, deg: 3}
+	case 2:
+		c := s.quad().Elevate()
+		return segment{P: [4]Point{c.P0, c.P1, c.P2, c.P3}, deg: 3}
+	}
+	return s
+}
+
+// morphSubpaths returns the subpaths of p that have segments, with all of
+// the segments converted to cubics
+func morphSubpaths(p Path) (sps []subpath) {
+	for _, sp := range p.subpaths() {
+		if len(sp.segs) == 0 {
+			continue
+		}
+		for i, s := range sp.segs {
+			sp.segs[i] = s.toCubic()
+		}
+		sps = append(sps, sp)
+	}
+	return
+}
+
+// center returns the average of the segment end points of the subpath
+func (sp subpath) center() (c Point) {
+	for _, s := range sp.segs {
+		c = c.Add(s.end())
+	}
+	return c.Mul(1 / float64(len(sp.segs)))
+}
+
+// collapsed returns a subpath with n cubic segments that are all at point c,
+// used to grow or shrink a subpath that has no partner in the other path
+func collapsed(c Point, n int, closed bool) subpath {
+	sp := subpath{start: c, closed: closed, segs: make([]segment, n)}
+	for i := range sp.segs {
+		sp.segs[i] = segment{P: [4]Point{c, c, c, c}, deg: 3}
+	}
+	return sp
+}
+
+// subdivideTo splits the longest segments of the subpath in half until it
+// has n segments
+func (sp subpath) subdivideTo(n int) subpath {
+	segs := sp.segs
+	lens := make([]float64, len(segs))
+	for i, s := range segs {
+		lens[i] = s.length()
+	}
+	for len(segs) < n {
+		k := 0
+		for i, l := range lens {
+			if l > lens[k] {
+				k = i
+			}
+		}
+		s0, s1 := segs[k].split(0.5)
+		segs = append(segs[:k], append([]segment{s0, s1}, segs[k+1:]...)...)
+		lens = append(lens[:k], append([]float64{lens[k] / 2, lens[k] / 2}, lens[k+1:]...)...)
+	}
+	sp.segs = segs
+	return sp
+}
+
+// alignTo rotates the segments of the closed subpath sp so that its
+// segment end points are as close as possible to those of a, which has the
+// same number of segments.
+func (sp subpath) alignTo(a subpath) subpath {
+	n := len(sp.segs)
+	best, bestK := math.Inf(1), 0
+	for k := 0; k < n; k++ {
+		var d float64
+		for i := 0; i < n; i++ {
+			v := sp.segs[(i+k)%n].end().Sub(a.segs[i].end())
+			d += v.Dot(v)
+		}
+		if d < best {
+			best, bestK = d, k
+		}
+	}
+	segs := append(append([]segment{}, sp.segs[bestK:]...), sp.segs[:bestK]...)
+	return subpath{start: segs[0].P[0], segs: segs, closed: true}
+}
+
+// NewMorph prepares the paths a and b for interpolation. Lines and quadratic
+// curves are converted to cubics, and the longest segments of the subpath with
+// fewer segments are split until each pair of subpaths has the same number.
+// Closed subpaths of b are reversed if they wind the other way from their
+// partner in a, and their start points are rotated to line up with it. A
+// subpath with no partner grows from or shrinks to its center. Where a closed
+// subpath is paired with an open one, the result is closed as the subpath of a
+// is for t below 0.5, and as that of b from 0.5 on.
+func NewMorph(a, b Path) *Morph {
+	m := &Morph{a: morphSubpaths(a), b: morphSubpaths(b)}
+	for len(m.a) < len(m.b) {
+		sp := m.b[len(m.a)]
+		m.a = append(m.a, collapsed(sp.center(), len(sp.segs), sp.closed))
+	}
+	for len(m.b) < len(m.a) {
+		sp := m.a[len(m.b)]
+		m.b = append(m.b, collapsed(sp.center(), len(sp.segs), sp.closed))
+	}
+	m.closed = make([][2]bool, len(m.a))
+	for i := range m.a {
+		sa, sb := m.a[i], m.b[i]
+		closed := sa.closed && sb.closed
+		if closed && sa.area()*sb.area() < 0 {
+			sb = sb.reverse()
+		}
+		if n := len(sb.segs); len(sa.segs) < n {
+			sa = sa.subdivideTo(n)
+		} else {
+			sb = sb.subdivideTo(len(sa.segs))
+		}
+		if closed {
+			sb = sb.alignTo(sa)
+		}
+		m.a[i], m.b[i], m.closed[i] = sa, sb, [2]bool{sa.closed, sb.closed}
+	}
+	return m
+}
+
+// At returns the path interpolated at t, which is a at 0 and b at 1. Values
+// outside of 0 to 1 extrapolate.
+func (m *Morph) At(t float64) (p Path) {
+	for i, sa := range m.a {
+		sb := m.b[i]
+		closed := m.closed[i][0]
+		if t >= 0.5 {
+			closed = m.closed[i][1]
+		}
+		sp := subpath{start: lerpP(t, sa.start, sb.start), closed: closed,
+			segs: make([]segment, len(sa.segs))}
+		for j, s := range sa.segs {
+			for k := range s.P {
+				sp.segs[j].P[k] = lerpP(t, s.P[k], sb.segs[j].P[k])
+			}
+			sp.segs[j].deg = 3
+		}
+		sp.addTo(&p)
+	}
+	return
+}
+
+// Interpolate returns the path between a and b at t, which is a at 0 and b
+// at 1. To interpolate the same paths at many values of t, use NewMorph.
+func Interpolate(a, b Path, t float64) Path {
+	return NewMorph(a, b).At(t)
+}
@@ -0,0 +1,131 @@
+// Nearest point and distance queries on paths
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"math"
+)
+
+// nearestSamples is the number of intervals a curve is sampled at to bracket
+// the local minima of the distance to a point
+const nearestSamples = 16
+
+// Nearest is the point of a path closest to a query point. Seg is the index of
+// the segment in the slice returned by Segments, and T is the parameter on
+// the segment. Tangent is the unit direction of the path at the point.
+type Nearest struct {
+	P       Point
+	Dist    float64
+	Seg     int
+	T       float64
+	Tangent Point
+}
+
+// deriv2 returns the second derivative of the segment with respect to t
+func (s segment) deriv2(t float64) Point {
+	switch s.deg {
+	case 1:
+		return Point{}
+	case 2:
+		return s.quad().Deriv2()
+	}
+	return s.cubic().Deriv2(t)
+}
+
+// boxDist2 returns the squared distance from q to the box from min to max
+func boxDist2(q, min, max Point) float64 {
+	dx := math.Max(0, math.Max(min.X-q.X, q.X-max.X))
+	dy := math.Max(0, math.Max(min.Y-q.Y, q.Y-max.Y))
+	return dx*dx + dy*dy
+}
+
+// nearest returns the parameter of the point of the segment closest to q and
+// its squared distance. The distance is sampled to bracket its local minima,
+// and each is refined with Newton's method on the derivative of the squared
+// distance, (B(t)-q)·B'(t).
+func (s segment) nearest(q Point) (bestT, bestD2 float64) {
+	if s.deg == 1 {
+		d := s.P[1].Sub(s.P[0])
+		if dd := d.Dot(d); dd > 0 {
+			bestT = math.Max(0, math.Min(1, q.Sub(s.P[0]).Dot(d)/dd))
+		}
+		p := s.eval(bestT).Sub(q)
+		return bestT, p.Dot(p)
+	}
+	var d2s [nearestSamples + 1]float64
+	for i := range d2s {
+		p := s.eval(float64(i) / nearestSamples).Sub(q)
+		d2s[i] = p.Dot(p)
+	}
+	bestT, bestD2 = 0, d2s[0]
+	if d2s[nearestSamples] < bestD2 {
+		bestT, bestD2 = 1, d2s[nearestSamples]
+	}
+	// A minimum near an end may lie between the end and the next sample, so
+	// the ends are refined too.
+	for i := 0; i <= nearestSamples; i++ {
+		if (i > 0 && d2s[i] > d2s[i-1]) || (i < nearestSamples && d2s[i] > d2s[i+1]) {
+			continue
+		}
+		lo := math.Max(0, float64(i-1)/nearestSamples)
+		hi := math.Min(1, float64(i+1)/nearestSamples)
+		t := float64(i) / nearestSamples
+		for j := 0; j < 20; j++ {
+			p, d1 := s.eval(t).Sub(q), s.deriv(t)
+			g := p.Dot(d1)
+			gd := d1.Dot(d1) + p.Dot(s.deriv2(t))
+			if gd <= 0 {
+				break
+			}
+			nt := t - g/gd
+			if nt < lo || nt > hi {
+				break
+			}
+			done := math.Abs(nt-t) < 1e-12
+			t = nt
+			if done {
+				break
+			}
+		}
+		if p := s.eval(t).Sub(q); p.Dot(p) < bestD2 {
+			bestT, bestD2 = t, p.Dot(p)
+		}
+		if d2s[i] < bestD2 {
+			bestT, bestD2 = float64(i)/nearestSamples, d2s[i]
+		}
+	}
+	return
+}
+
+// NearestPoint returns the point of the path closest to q. It returns false
+// if the path has no segments. Curves are searched directly rather than
+// flattened, so the result is exact to within rounding.
+func (p Path) NearestPoint(q Point) (n Nearest, ok bool) {
+	bestD2 := math.Inf(1)
+	for i, s := range p.segmentList() {
+		if min, max := s.hull(); boxDist2(q, min, max) >= bestD2 {
+			continue
+		}
+		t, d2 := s.nearest(q)
+		if d2 < bestD2 {
+			bestD2 = d2
+			n = Nearest{P: s.eval(t), Seg: i, T: t, Tangent: s.tangent(t).Unit()}
+			ok = true
+		}
+	}
+	n.Dist = math.Sqrt(bestD2)
+	return
+}
+
+// DistanceToStroke returns the distance from q to the edge of the path
+// stroked with the given width, which is negative inside the stroke. It is
+// exact for round caps and joins; other caps and joins are treated as round.
+// The distance from an empty path is infinite.
+func (p Path) DistanceToStroke(q Point, width float64) float64 {
+	n, ok := p.NearestPoint(q)
+	if !ok {
+		return math.Inf(1)
+	}
+	return n.Dist - width/2
+}
@@ -0,0 +1,133 @@
+// Path reversal and orientation
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+// Orientation is the direction in which a subpath winds around the region it
+// encloses, as seen on the screen with y increasing downward.
+type Orientation int8
+
+// Orientation constants
+const (
+	CounterClockwise Orientation = iota - 1
+	NoOrientation                // the subpath encloses no area
+	Clockwise
+)
+
+// orientTol is the flattening tolerance used to test the nesting of subpaths
+const orientTol = 0.1
+
+// area returns the signed area swept by the segment about the origin, which is
+// the integral of (x*dy - y*dx)/2. The integrand is a polynomial of at most
+// degree 5, so Gauss-Legendre quadrature gives it exactly.
+func (s segment) area() (a float64) {
+	if s.deg == 1 {
+		return s.P[0].Cross(s.P[1]) / 2
+	}
+	for i, x := range glX {
+		t := (x + 1) / 2
+		a += glW[i] * s.eval(t).Cross(s.deriv(t))
+	}
+	return a / 4
+}
+
+// reverse returns the subpath traced in the opposite direction. A closed
+// subpath keeps its start point.
+func (sp subpath) reverse() subpath {
+	r := subpath{start: sp.start, closed: sp.closed, segs: make([]segment, len(sp.segs))}
+	if n := len(sp.segs); n > 0 && !sp.closed {
+		r.start = sp.segs[n-1].end()
+	}
+	for i, s := range sp.segs {
+		r.segs[len(sp.segs)-1-i] = s.reverse()
+	}
+	return r
+}
+
+// area returns the signed area enclosed by the subpath, which is implicitly
+// closed if it is open
+func (sp subpath) area() (a float64) {
+	cur := sp.start
+	for _, s := range sp.segs {
+		a += s.area()
+		cur = s.end()
+	}
+	return a + cur.Cross(sp.start)/2
+}
+
+// Reverse returns the path with each of its subpaths traced in the opposite
+// direction, including its curves. The order of the subpaths is kept.
+func (p Path) Reverse() (q Path) {
+	for _, sp := range p.subpaths() {
+		sp.reverse().addTo(&q)
+	}
+	return
+}
+
+// SignedAreas returns the area enclosed by each subpath, in square pixels.
+// Open subpaths are treated as closed, as they are when filled. The area is
+// positive for a subpath that winds clockwise on the screen and negative for
+// one that winds counterclockwise. Parts that wind in opposite directions,
+// such as the lobes of a figure eight, offset each other.
+func (p Path) SignedAreas() []float64 {
+	sps := p.subpaths()
+	areas := make([]float64, len(sps))
+	for i, sp := range sps {
+		areas[i] = sp.area()
+	}
+	return areas
+}
+
+// Orientations returns the Orientation of each subpath, according to the
+// sign of its area.
+func (p Path) Orientations() []Orientation {
+	areas := p.SignedAreas()
+	os := make([]Orientation, len(areas))
+	for i, a := range areas {
+		switch {
+		case a > 0:
+			os[i] = Clockwise
+		case a < 0:
+			os[i] = CounterClockwise
+		}
+	}
+	return os
+}
+
+// NormalizeOrientation returns the path with each outer subpath winding in the
+// direction outer and each hole winding in the opposite direction, so that
+// the path fills the same with the nonzero and even-odd rules. A subpath is a
+// hole if it is inside an odd number of the other subpaths. The subpaths
+// should not cross each other; Boolean(UnionOp, p, nil, nz) removes crossings
+// and gives a path where outer subpaths wind clockwise.
+func (p Path) NormalizeOrientation(outer Orientation) (q Path) {
+	sps := p.subpaths()
+	inside := make([]*windingIndex, len(sps))
+	for i, sp := range sps {
+		poly := sp.polyline(orientTol)
+		poly = append(poly, sp.start) // implicit close for filling
+		edges := make([]bEdge, 0, len(poly))
+		for j := 1; j < len(poly); j++ {
+			edges = append(edges, bEdge{a: poly[j-1], b: poly[j]})
+		}
+		inside[i] = newWindingIndex(edges)
+	}
+	for i, sp := range sps {
+		depth := 0
+		for j := range sps {
+			if j != i && inside[j].winding(sp.start) != 0 {
+				depth++
+			}
+		}
+		want := outer
+		if depth%2 == 1 {
+			want = -outer
+		}
+		a := sp.area()
+		if (a > 0 && want == CounterClockwise) || (a < 0 && want == Clockwise) {
+			sp = sp.reverse()
+		}
+		sp.addTo(&q)
+	}
+	return
+}
@@ -0,0 +1,119 @@
+// Capture of stroke outlines as paths
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"golang.org/x/image/math/fixed"
+)
+
+type (
+	// outlinePiece is a run of outline commands after a start point
+	outlinePiece struct {
+		start, end fixed.Point26_6
+		cmds       Path
+	}
+
+	// outlineCollector is an Adder that records the pieces of a stroke
+	// outline. The Stroker sends the outline as open pieces whose ends meet,
+	// which the scanner accumulates into closed contours, so the collector
+	// links the pieces end to start into closed subpaths.
+	outlineCollector struct {
+		pieces []outlinePiece
+	}
+)
+
+// StrokeOutline returns the outline of the stroke of p, using the current
+// stroke settings, as a Path instead of rasterizing it. The outline is made of
+// closed subpaths that overlap, and must be filled with the nonzero winding rule,
+// unless the stroke is overlap free, when it is their union.
+// Caps and joins drawn with bezier curves, such as the round, cubic and
+// quadratic caps and gaps and the arcs of arc joins, are kept as curves.
+// Stroked curves are flattened.
+func (r *Stroker) StrokeOutline(p Path) Path {
+	return r.outline(r, p)
+}
+
+// StrokeOutline returns the outline of the dashed stroke of p, using the current
+// stroke settings, as a Path instead of rasterizing it. See Stroker.StrokeOutline.
+func (r *Dasher) StrokeOutline(p Path) Path {
+	return r.outline(r, p)
+}
+
+// outline sends p to the Adder q, which must be r or a type that embeds it,
+// and returns the captured stroke outline.
+func (r *Stroker) outline(q Adder, p Path) (o Path) {
+	c := &outlineCollector{}
+	saved := r.out
+	r.out = c
+	p.AddTo(q)
+	r.out = saved
+	c.addTo(&o)
+	if r.overlapFree {
+		o = Boolean(UnionOp, o, nil, true)
+	}
+	return
+}
+
+// Start starts a new piece
+func (c *outlineCollector) Start(a fixed.Point26_6) {
+	c.pieces = append(c.pieces, outlinePiece{start: a, end: a})
+}
+
+// Line adds a line to the current piece
+func (c *outlineCollector) Line(b fixed.Point26_6) {
+	pc := &c.pieces[len(c.pieces)-1]
+	pc.cmds.Line(b)
+	pc.end = b
+}
+
+// QuadBezier adds a quadratic bezier to the current piece
+func (c *outlineCollector) QuadBezier(b, d fixed.Point26_6) {
+	pc := &c.pieces[len(c.pieces)-1]
+	pc.cmds.QuadBezier(b, d)
+	pc.end = d
+}
+
+// CubeBezier adds a cubic bezier to the current piece
+func (c *outlineCollector) CubeBezier(b, d, e fixed.Point26_6) {
+	pc := &c.pieces[len(c.pieces)-1]
+	pc.cmds.CubeBezier(b, d, e)
+	pc.end = e
+}
+
+// Stop is a no-op; pieces are linked when the outline is complete
+func (c *outlineCollector) Stop(closeLoop bool) {}
+
+// addTo links the collected pieces into closed subpaths and adds them to q.
+// A chain that does not make it back to its start is closed with a line.
+func (c *outlineCollector) addTo(q Adder) {
+	from := make(map[fixed.Point26_6][]int)
+	for i, pc := range c.pieces {
+		if len(pc.cmds) > 0 {
+			from[pc.start] = append(from[pc.start], i)
+		}
+	}
+	used := make([]bool, len(c.pieces))
+	for i, pc := range c.pieces {
+		if used[i] || len(pc.cmds) == 0 {
+			continue
+		}
+		q.Start(pc.start)
+		for cur := i; cur >= 0; {
+			used[cur] = true
+			pc := c.pieces[cur]
+			pc.cmds.addCmdsTo(q)
+			cur = -1
+			if pc.end == c.pieces[i].start {
+				break
+			}
+			for _, k := range from[pc.end] {
+				if !used[k] {
+					cur = k
+					break
+				}
+			}
+		}
+		q.Stop(true)
+	}
+}
@@ -0,0 +1,46 @@
+// Overlap free stroke outlines
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+// SetOverlapFree sets whether the stroke is drawn as one outline that does
+// not overlap itself. The Stroker normally sends the sides, joins and caps of
+// a stroke to the scanner as separate pieces that overlap, which only fill
+// correctly by the nonzero winding rule. When on, the pieces are collected
+// until Draw, and their union is filled, so each pixel of the stroke is
+// covered once. StrokeOutline also returns the union, which has no overlaps
+// and so fills the same by the nonzero and even-odd rules. Draw must be
+// called on the Stroker or Dasher rather than on its Scanner.
+func (r *Stroker) SetOverlapFree(on bool) {
+	r.overlapFree = on
+	r.merged = nil
+}
+
+// OverlapFree returns true if the stroke is drawn as one outline
+func (r *Stroker) OverlapFree() bool {
+	return r.overlapFree
+}
+
+// Draw fills the union of the collected stroke outline, if the stroke is
+// overlap free, and draws the scanner, with the opacity of a hairline
+func (r *Stroker) Draw() {
+	if r.merged != nil {
+		var o Path
+		r.merged.addTo(&o)
+		r.merged = nil
+		r.first = r.a // as for addOutline
+		Boolean(UnionOp, o, nil, true).AddTo(&r.Filler)
+	}
+	if sc, ok := r.Scanner.(opacityScanner); ok { // for this stroke only
+		sc.setOpacity(r.hairAlpha())
+		defer sc.setOpacity(1)
+	}
+	r.Filler.Draw()
+}
+
+// Clear resets the stroker, discarding any collected stroke outline
+func (r *Stroker) Clear() {
+	r.merged = nil
+	r.w.buf.Clear()
+	r.Filler.Clear()
+}
@@ -0,0 +1,533 @@
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+// Created 2018 by S.R.Wiley
+package rasterx_test
+
+import (
+	"image"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/srwiley/rasterx"
+	"golang.org/x/image/math/fixed"
+)
+
+func getCirclePath(cx, cy, r float64) (p Path) {
+	AddCircle(cx, cy, r, &p)
+	return
+}
+
+func TestPathLength(t *testing.T) {
+	var p Path
+	p.Start(ToFixedP(10, 10))
+	p.Line(ToFixedP(40, 10))
+	p.Line(ToFixedP(40, 50))
+	p.Stop(true)
+	if l := p.Length(); math.Abs(l-120) > 1e-6 {
+		t.Error("closed triangle length wrong", l)
+	}
+	if pt := p.PointAtLength(35); math.Abs(pt.X-40) > 1e-6 || math.Abs(pt.Y-15) > 1e-6 {
+		t.Error("point at length wrong", pt)
+	}
+	if tan := p.TangentAtLength(35); math.Abs(tan.X) > 1e-6 || math.Abs(tan.Y-1) > 1e-6 {
+		t.Error("tangent at length wrong", tan)
+	}
+
+	c := getCirclePath(100, 100, 50)
+	if l := c.Length(); math.Abs(l-2*math.Pi*50) > 0.05 {
+		t.Error("circle length wrong", l, 2*math.Pi*50)
+	}
+	m := NewPathMeasure(c)
+	quarter := m.Length() / 4
+	pt := m.PointAtLength(quarter)
+	if math.Abs(pt.Sub(Point{X: 100, Y: 50}).Len()) > 0.1 {
+		t.Error("circle quarter point wrong", pt)
+	}
+	// Curve lengths are compared against a fine polyline
+	var q Path
+	q.Start(ToFixedP(0, 0))
+	q.CubeBezier(ToFixedP(100, 0), ToFixedP(0, 100), ToFixedP(100, 100))
+	var poly float64
+	last := Point{}
+	const n = 20000
+	for i := 1; i <= n; i++ {
+		s := float64(i) / n
+		ms := 1 - s
+		x := 3*ms*ms*s*100 + s*s*s*100
+		y := 3*ms*s*s*100 + s*s*s*100
+		poly += math.Hypot(x-last.X, y-last.Y)
+		last = Point{X: x, Y: y}
+	}
+	if l := q.Length(); math.Abs(l-poly) > 1e-3 {
+		t.Error("cubic length wrong", l, poly)
+	}
+	if lens := getOpenCubicPath2().SubpathLengths(); len(lens) != 2 || math.Abs(lens[0]-lens[1]) > 1e-3 {
+		t.Error("mirrored subpath lengths differ", lens)
+	}
+}
+
+func TestDasherPathLength(t *testing.T) {
+	var p Path
+	p.Start(ToFixedP(0, 0))
+	p.Line(ToFixedP(200, 0))
+	dashes := []float64{10, 5}
+	d := NewDasher(10, 10, NewScannerGV(10, 10, image.NewRGBA(image.Rect(0, 0, 1, 1)), image.Rect(0, 0, 1, 1)))
+	d.SetStroke(64, 4*64, nil, nil, nil, Round, dashes, 2)
+	d.SetPathLength(p, 100)
+	d.SetPathLength(p, 100) // the scale does not compound
+	if d.Dashes[0] != 20*64 || d.Dashes[1] != 10*64 || d.DashOffset != 4*64 {
+		t.Error("wrong scaled dashes", d.Dashes, d.DashOffset)
+	}
+	d.SetPathLength(p, 0)
+	if d.Dashes[0] != 10*64 || d.Dashes[1] != 5*64 || d.DashOffset != 2*64 {
+		t.Error("wrong unscaled dashes", d.Dashes, d.DashOffset)
+	}
+	d.SetPathLength(p, 400)
+	d.SetStroke(64, 4*64, nil, nil, nil, Round, dashes, 2)
+	if d.Dashes[0] != 10*64 || dashes[0] != 10 {
+		t.Error("SetStroke should replace the scaled dashes", d.Dashes, dashes)
+	}
+
+	// Dashes that all scale to nothing stroke the path without dashes
+	// rather than looping forever on dashes of zero length
+	var short Path
+	short.Start(ToFixedP(20, 50))
+	short.Line(ToFixedP(30, 50))
+	solid := func(sc Scanner) Adder {
+		d := NewDasher(100, 100, sc)
+		d.SetStroke(4*64, 4*64, ButtCap, nil, nil, Miter, nil, 0)
+		return d
+	}
+	done := make(chan []uint8)
+	go func() {
+		done <- strokeAlpha(100, 100, short, func(sc Scanner) Adder {
+			d := NewDasher(100, 100, sc)
+			d.SetStroke(4*64, 4*64, ButtCap, nil, nil, Miter, []float64{1, 1}, 0)
+			d.SetPathLength(short, 1000)
+			return d
+		})
+	}()
+	select {
+	case got := <-done:
+		if mx, n := alphaDiff(got, strokeAlpha(100, 100, short, solid)); n > 0 {
+			t.Error("vanishing dashes should stroke the whole path", mx, n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("dashing with vanishing dashes did not finish")
+	}
+
+	// Dashes are measured along curves by arc length, so half of the
+	// pattern scaled to a circle ends where the circle is half drawn
+	c := getCirclePath(100, 100, 80)
+	half := c.Trim(0, 0.5)
+	for _, tol := range []float64{0, 0.1} {
+		dashed := strokeAlpha(200, 200, c, func(sc Scanner) Adder {
+			d := NewDasher(200, 200, sc)
+			d.SetTolerance(tol)
+			d.SetStroke(8*64, 4*64, ButtCap, nil, nil, Miter, []float64{1, 1}, 0)
+			d.SetPathLength(c, 2)
+			return d
+		})
+		want := strokeAlpha(200, 200, half, func(sc Scanner) Adder {
+			d := NewDasher(200, 200, sc)
+			d.SetTolerance(tol)
+			d.SetStroke(8*64, 4*64, ButtCap, nil, nil, Miter, nil, 0)
+			return d
+		})
+		if mx, n := alphaDiff(dashed, want); mx > 64 {
+			t.Error("dash should end half way around the circle", tol, mx, n)
+		}
+	}
+}
+
+func TestPathTrim(t *testing.T) {
+	var sq Path
+	sq.Start(ToFixedP(0, 0))
+	sq.Line(ToFixedP(100, 0))
+	sq.Line(ToFixedP(100, 100))
+	sq.Line(ToFixedP(0, 100))
+	sq.Stop(true)
+
+	half := sq.Trim(0.25, 0.75)
+	if l := half.Length(); math.Abs(l-200) > 1e-6 {
+		t.Error("trimmed length wrong", l)
+	}
+	if s := half.String(); s != "M100.000,0.000 L100.000,100.000 L0.000,100.000" {
+		t.Error("trimmed path wrong", s)
+	}
+	// Wrapping past the end of a closed subpath joins the pieces at the start point
+	wrap := sq.TrimOffset(0.5, 1, 0.375, TrimSimultaneous)
+	if s := wrap.String(); s != "M0.000,50.000 L0.000,0.000 L100.000,0.000 L100.000,50.000" {
+		t.Error("wrapped trim wrong", s)
+	}
+	if s := sq.Trim(0, 1).String(); s != sq.String() {
+		t.Error("full trim should keep the closed path", s)
+	}
+
+	two := getOpenCubicPath2()
+	lens := two.SubpathLengths()
+	ind := two.TrimOffset(0, 0.5, 0, TrimIndividual)
+	if l := ind.Length(); math.Abs(l-lens[0]) > 0.05 {
+		t.Error("individual trim length wrong", l, lens[0])
+	}
+	sim := two.Trim(0, 0.5)
+	if l := sim.Length(); math.Abs(l-lens[0]) > 0.05 {
+		t.Error("simultaneous trim length wrong", l, lens[0])
+	}
+	if l := len(sim.SubpathLengths()); l != 2 {
+		t.Error("simultaneous trim should keep both subpaths", l)
+	}
+	c := getCirclePath(100, 100, 50)
+	arc := c.Trim(0.1, 0.3)
+	if l, want := arc.Length(), c.Length()*0.2; math.Abs(l-want) > 0.02 {
+		t.Error("circle trim length wrong", l, want)
+	}
+}
+
+func TestPathFlatten(t *testing.T) {
+	var p Path
+	p.Start(ToFixedP(0, 0))
+	for _, x := range []float64{25, 50, 75, 100} {
+		p.Line(ToFixedP(x, 0))
+	}
+	p.Line(ToFixedP(100, 100))
+	p.Line(ToFixedP(0, 100))
+	p.Stop(true)
+	p.Start(ToFixedP(200, 0))
+	p.Line(ToFixedP(210, 0))
+	p.Line(ToFixedP(220, 0))
+	p.QuadBezier(ToFixedP(240, 0), ToFixedP(240, 20))
+
+	polys := p.Flatten(0.1, false)
+	if len(polys) != 2 || len(polys[0].Points) != 8 {
+		t.Fatal("wrong polylines", polys)
+	}
+	if pts := polys[0].Points; !polys[0].Closed || pts[0] != pts[7] {
+		t.Error("closed polyline should repeat its first point", polys[0])
+	}
+	if polys[1].Closed {
+		t.Error("open subpath gave a closed polyline")
+	}
+	if pts := polys[1].Points; pts[len(pts)-1] != (Point{240, 20}) {
+		t.Error("open polyline should end at the path end", pts)
+	}
+	polys = p.Flatten(0.1, true)
+	if want := []Point{{0, 0}, {100, 0}, {100, 100}, {0, 100}, {0, 0}}; !reflect.DeepEqual(polys[0].Points, want) {
+		t.Error("collinear points not dropped", polys[0])
+	}
+	if polys[1].Points[1] != (Point{220, 0}) {
+		t.Error("collinear points not dropped on open polyline", polys[1])
+	}
+	// An open subpath that ends where it starts has the same points as the
+	// closed one, and only Closed tells them apart
+	var open, closed Path
+	for _, q := range []*Path{&open, &closed} {
+		q.Start(ToFixedP(0, 0))
+		q.Line(ToFixedP(10, 0))
+		q.Line(ToFixedP(10, 10))
+	}
+	open.Line(ToFixedP(0, 0))
+	open.Stop(false)
+	closed.Stop(true)
+	po, pc := open.Flatten(0, false), closed.Flatten(0, false)
+	if !reflect.DeepEqual(po[0].Points, pc[0].Points) {
+		t.Error("open and closed triangles should have the same points", po, pc)
+	}
+	if po[0].Closed || !pc[0].Closed {
+		t.Error("wrong closedness", po[0].Closed, pc[0].Closed)
+	}
+
+	const tol = 0.05
+	circle := getCirclePath(100, 100, 50)
+	ring := circle.Flatten(tol, false)[0].Points
+	for i := 1; i < len(ring); i++ {
+		m := ring[i-1].Add(ring[i]).Mul(0.5)
+		if d := m.Sub(Point{100, 100}).Len(); d < 50-tol-0.02 || d > 50+0.02 {
+			t.Error("circle flattening exceeds tolerance", d)
+		}
+	}
+	if n := len(getCirclePath(100, 100, 50).Flatten(1, false)[0].Points); n >= len(ring) {
+		t.Error("larger tolerance should give fewer points", n, len(ring))
+	}
+	if n := len(circle.Flatten(0, false)[0].Points); n < 10 {
+		t.Error("default flattening gave too few points", n)
+	}
+}
+
+func TestPathTransform(t *testing.T) {
+	p := getCirclePath(100, 100, 50)
+	m := Identity.Translate(10, 20).Rotate(math.Pi/6).Scale(2, 0.5)
+	tp := p.Transform(m)
+	ma := &MatrixAdder{M: m}
+	var streamed Path
+	ma.Adder = &streamed
+	p.AddTo(ma)
+	if len(tp) != len(streamed) {
+		t.Fatal("transformed path has wrong length", len(tp), len(streamed))
+	}
+	for i := range tp {
+		if d := tp[i] - streamed[i]; d < -1 || d > 1 {
+			t.Error("transform differs from MatrixAdder", i, tp[i], streamed[i])
+		}
+	}
+	if p.String() != getCirclePath(100, 100, 50).String() {
+		t.Error("Transform changed the source path")
+	}
+
+	// Transforms of a PathF keep full precision, so the inverse transform
+	// restores the original path exactly after rounding.
+	pf := p.ToPathF()
+	if pf.ToPath().String() != p.String() {
+		t.Error("PathF round trip changed the path")
+	}
+	pf.TransformInPlace(m)
+	if pf.ToPath().String() != tp.String() {
+		t.Error("PathF transform differs from Path transform", pf.ToPath(), tp)
+	}
+	pf.TransformInPlace(m.Invert())
+	if pf.ToPath().String() != p.String() {
+		t.Error("inverse transform did not restore the path", pf)
+	}
+	var rec PathF
+	p.AddTo(&rec)
+	if rec.ToPath().String() != p.String() {
+		t.Error("PathF as Adder records a different path", rec)
+	}
+	var q PathF
+	q.MoveTo(1, 2)
+	q.LineTo(3.5, 4)
+	q.QuadTo(5, 6, 7, 8)
+	q.Close()
+	if s := q.String(); s != "M1.000,2.000 L3.500,4.000 Q5.000,6.000,7.000,8.000 Z" {
+		t.Error("wrong PathF string", s)
+	}
+}
+
+func TestPathOrientation(t *testing.T) {
+	sq := getSquarePath(10, 10, 110, 60)
+	if a := sq.SignedAreas(); len(a) != 1 || math.Abs(math.Abs(a[0])-5000) > 1e-9 {
+		t.Error("wrong square area", a)
+	}
+	circle := getCirclePath(100, 100, 50)
+	ca := circle.SignedAreas()[0]
+	if math.Abs(math.Abs(ca)-math.Pi*2500) > 5 {
+		t.Error("wrong circle area", ca)
+	}
+	rc := circle.Reverse()
+	if ra := rc.SignedAreas()[0]; math.Abs(ra+ca) > 1e-9 {
+		t.Error("reversed circle area should be negated", ra, ca)
+	}
+	if rc.Reverse().String() != circle.String() {
+		t.Error("reversing twice should restore the path", rc.Reverse())
+	}
+	if l := rc.Length(); math.Abs(l-circle.Length()) > 1e-9 {
+		t.Error("reversed length differs", l)
+	}
+	if rp, p := rc.PointAtLength(10), circle.PointAtLength(circle.Length()-10); rp.Sub(p).Len() > 1e-6 {
+		t.Error("reversed path does not run backwards", rp, p)
+	}
+	var open Path
+	open.Start(ToFixedP(0, 0))
+	open.Line(ToFixedP(10, 0))
+	open.QuadBezier(ToFixedP(20, 0), ToFixedP(20, 10))
+	if s := open.Reverse().String(); s != "M20.000,10.000 Q20.000,0.000,10.000,0.000 L0.000,0.000" {
+		t.Error("wrong reversed open path", s)
+	}
+
+	// A ring with both circles winding the same way, and an island inside the hole
+	var ring Path
+	ring = append(ring, getCirclePath(100, 100, 80)...)
+	ring = append(ring, getCirclePath(100, 100, 50)...)
+	ring = append(ring, getCirclePath(100, 100, 20)...)
+	for _, outer := range []Orientation{Clockwise, CounterClockwise} {
+		os := ring.NormalizeOrientation(outer).Orientations()
+		if want := []Orientation{outer, -outer, outer}; !reflect.DeepEqual(os, want) {
+			t.Error("wrong normalized orientations", os, want)
+		}
+	}
+	if os := Boolean(UnionOp, sq, nil, true).Orientations(); os[0] != Clockwise {
+		t.Error("boolean result should wind clockwise", os)
+	}
+}
+
+func TestNearestPoint(t *testing.T) {
+	circle := getCirclePath(110, 60, 30)
+	n, ok := circle.NearestPoint(Point{200, 60})
+	if !ok || n.P.Sub(Point{140, 60}).Len() > 0.01 || math.Abs(n.Dist-60) > 0.01 ||
+		math.Abs(math.Abs(n.Tangent.Y)-1) > 1e-3 {
+		t.Error("wrong nearest point on circle", n)
+	}
+	if d := circle.DistanceToStroke(Point{110, 95}, 4); math.Abs(d-3) > 0.05 {
+		t.Error("wrong distance to stroke", d)
+	}
+	if d := circle.DistanceToStroke(Point{110, 91}, 4); d >= 0 {
+		t.Error("point in stroke should have negative distance", d)
+	}
+	if _, ok := (Path{}).NearestPoint(Point{}); ok {
+		t.Error("empty path should have no nearest point")
+	}
+
+	// Compare with a dense search of a wavy cubic
+	var p Path
+	p.Start(ToFixedP(10, 100))
+	p.CubeBezier(ToFixedP(250, -150), ToFixedP(-50, 350), ToFixedP(190, 100))
+	p.QuadBezier(ToFixedP(250, 0), ToFixedP(100, 20))
+	segs := p.Segments()
+	for _, q := range []Point{{100, 100}, {0, 0}, {150, 50}, {60, 150}, {200, 200}, {120, 40}} {
+		n, _ := p.NearestPoint(q)
+		best := math.Inf(1)
+		for _, s := range segs {
+			for i := 0; i <= 20000; i++ {
+				best = math.Min(best, s.Eval(float64(i)/20000).Sub(q).Len())
+			}
+		}
+		if n.Dist > best+1e-9 || best-n.Dist > 0.01 {
+			t.Error("nearest point differs from dense search", q, n.Dist, best)
+		}
+		if s := segs[n.Seg]; s.Eval(n.T).Sub(n.P).Len() > 1e-9 || math.Abs(n.P.Sub(q).Len()-n.Dist) > 1e-9 {
+			t.Error("nearest point inconsistent", q, n)
+		}
+		// The nearest point of a smooth part is where the path is square to q
+		if n.T > 0 && n.T < 1 && math.Abs(n.Tangent.Dot(q.Sub(n.P).Unit())) > 1e-6 {
+			t.Error("path not square to the query at the nearest point", q, n)
+		}
+	}
+	// A minimum between an end and the next sample is refined
+	var flat Path
+	flat.Start(ToFixedP(0, 0))
+	flat.QuadBezier(ToFixedP(100, 0), ToFixedP(200, 1))
+	if n, _ := flat.NearestPoint(Point{4, 5}); math.Abs(n.Dist-5) > 0.01 || n.T <= 0 {
+		t.Error("minimum near the start not refined", n)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	const wx, wy = 200, 200
+	sq := getSquarePath(50, 50, 150, 150)
+	circle := getCirclePath(100, 100, 60).Reverse()
+	m := NewMorph(sq, circle)
+	for _, c := range []struct {
+		at   float64
+		want Path
+	}{{0, sq}, {1, circle}} {
+		got := m.At(c.at)
+		if mx, n := alphaDiff(fillAlpha(wx, wy, got), fillAlpha(wx, wy, c.want)); n > 0 {
+			t.Error("morph end renders differently", c.at, mx, n)
+		}
+	}
+	// The circle is reversed to wind with the square, so the shape does not
+	// turn inside out on the way.
+	sa, ca := sq.SignedAreas()[0], math.Abs(circle.SignedAreas()[0])
+	for _, at := range []float64{0.25, 0.5, 0.75} {
+		a := m.At(at).SignedAreas()[0]
+		if a < math.Min(sa, ca)-1 || a > math.Max(sa, ca)+1 {
+			t.Error("interpolated area out of range", at, a, sa, ca)
+		}
+	}
+	if s := Interpolate(sq, getSquarePath(60, 60, 160, 160), 0.5).String(); s !=
+		Interpolate(getSquarePath(55, 55, 155, 155), getSquarePath(55, 55, 155, 155), 0).String() {
+		t.Error("interpolated squares should be the middle square", s)
+	}
+	// A subpath with no partner grows from its center
+	var two Path
+	two = append(two, sq...)
+	two = append(two, getCirclePath(20, 20, 10)...)
+	start := Interpolate(sq, two, 0)
+	if n := len(start.SubpathLengths()); n != 2 {
+		t.Error("morph should have two subpaths", n)
+	} else if l := start.SubpathLengths()[1]; l > 0.1 {
+		t.Error("unmatched subpath should start collapsed", l)
+	}
+	// A closed subpath paired with an open one is closed at the closed end
+	var open Path
+	open.Start(ToFixedP(40, 160))
+	open.Line(ToFixedP(100, 40))
+	open.Line(ToFixedP(160, 160))
+	open.Stop(false)
+	m = NewMorph(open, sq)
+	for _, c := range []struct {
+		at     float64
+		closed bool
+	}{{0, false}, {0.25, false}, {0.75, true}, {1, true}} {
+		p := m.At(c.at)
+		if closed := p[len(p)-1] == fixed.Int26_6(PathClose); closed != c.closed {
+			t.Error("morph closed wrongly", c.at, closed)
+		}
+	}
+	if p := NewMorph(sq, open).At(1); p[len(p)-1] == fixed.Int26_6(PathClose) {
+		t.Error("morph to an open subpath should end open")
+	}
+	stroke := func(p Path) []uint8 {
+		return strokeAlpha(wx, wy, p, func(sc Scanner) Adder {
+			s := NewStroker(wx, wy, sc)
+			s.SetStroke(10*64, 4*64, ButtCap, nil, nil, Miter)
+			return s
+		})
+	}
+	if mx, n := alphaDiff(stroke(m.At(1)), stroke(sq)); n > 0 {
+		t.Error("closed morph end strokes differently", mx, n)
+	}
+}
+
+func TestMarkers(t *testing.T) {
+	var p Path
+	p.Start(ToFixedP(10, 50))
+	p.Line(ToFixedP(100, 50))
+	p.QuadBezier(ToFixedP(150, 50), ToFixedP(150, 100))
+	p.Stop(false)
+	p.Start(ToFixedP(200, 200))
+	p.Line(ToFixedP(250, 200))
+	p.Line(ToFixedP(250, 250))
+	p.Stop(true)
+	vs := p.MarkerVertices()
+	if len(vs) != 3+4 {
+		t.Fatal("wrong number of vertices", len(vs))
+	}
+	if vs[0].TTan != (fixed.Point26_6{}) || vs[2].LTan != (fixed.Point26_6{}) {
+		t.Error("open subpath ends should have one tangent", vs[0], vs[2])
+	}
+	if vs[3].P != vs[6].P || vs[3].TTan != vs[6].TTan || vs[3].LTan != vs[6].LTan {
+		t.Error("closed subpath should start and end alike", vs[3], vs[6])
+	}
+
+	var arrow Path
+	arrow.Start(ToFixedP(0, -1))
+	arrow.Line(ToFixedP(2, 0))
+	arrow.Line(ToFixedP(0, 1))
+	arrow.Stop(true)
+	mk := &Marker{Path: arrow, Orient: OrientAutoStartReverse}
+	bounds := func(q Path) (min, max Point) {
+		min, max = Point{X: math.Inf(1), Y: math.Inf(1)}, Point{X: math.Inf(-1), Y: math.Inf(-1)}
+		for _, poly := range q.Flatten(0.1, false) {
+			for _, pt := range poly.Points {
+				min = Point{X: math.Min(min.X, pt.X), Y: math.Min(min.Y, pt.Y)}
+				max = Point{X: math.Max(max.X, pt.X), Y: math.Max(max.Y, pt.Y)}
+			}
+		}
+		return
+	}
+	near := func(a, b Point) bool { return a.Sub(b).Len() < 0.1 }
+
+	// The start arrow points back, to the left, and the end arrow of the
+	// open subpath points down
+	var start, end Path
+	p.AddMarkers(&start, mk, nil, nil, 4, Identity)
+	p[:len(p)-10].AddMarkers(&end, nil, nil, mk, 4, Identity)
+	if min, max := bounds(start); !near(min, Point{2, 46}) || !near(max, Point{10, 54}) {
+		t.Error("wrong start marker", min, max)
+	}
+	if min, max := bounds(end); !near(min, Point{146, 100}) || !near(max, Point{154, 108}) {
+		t.Error("wrong end marker", min, max)
+	}
+	var mids Path
+	p.AddMarkers(&mids, nil, &Marker{Path: arrow, Orient: OrientAuto, Units: MarkerUserSpace}, nil, 4, Identity.Scale(2, 2))
+	if n := len(mids.SubpathLengths()); n != 5 {
+		t.Error("wrong number of mid markers", n)
+	}
+	var fixedAngle Path
+	p.AddMarkers(&fixedAngle, &Marker{Path: arrow, RefX: 2, Angle: math.Pi / 2, Units: MarkerUserSpace}, nil, nil, 4, Identity)
+	if min, max := bounds(fixedAngle); !near(min, Point{9, 48}) || !near(max, Point{11, 50}) {
+		t.Error("wrong fixed angle marker", min, max)
+	}
+}
@@ -0,0 +1,197 @@
+// Floating point paths and path transforms
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"golang.org/x/image/math/fixed"
+)
+
+// PathF is a path in floating point pixel coordinates. It has the same layout
+// as Path; a PathCommand value is followed by zero to three points, each
+// stored as an x and a y value. It keeps full precision through repeated
+// transforms, so transformed geometry can be cached and reused.
+type PathF []float64
+
+// cmdPoints returns the number of points that follow the path command
+func cmdPoints(c PathCommand) int {
+	switch c {
+	case PathMoveTo, PathLineTo:
+		return 1
+	case PathQuadTo:
+		return 2
+	case PathCubicTo:
+		return 3
+	case PathClose:
+		return 0
+	}
+	panic("rasterx: bad path command")
+}
+
+// ToFixed converts a floating point pixel value to fixed point,
+// rounding to the nearest value
+func ToFixed(x float64) fixed.Int26_6 {
+	return fixed.Int26_6(math.Round(x * 64))
+}
+
+// Transform returns a copy of the path with all of its points transformed by
+// m. Since Bezier curves are preserved by affine transforms, mapping the
+// control points maps the curves exactly; the only error is the rounding of
+// each point to the nearest fixed point value.
+func (p Path) Transform(m Matrix2D) Path {
+	q := make(Path, len(p))
+	copy(q, p)
+	q.TransformInPlace(m)
+	return q
+}
+
+// TransformInPlace transforms all of the points of the path by m
+func (p Path) TransformInPlace(m Matrix2D) {
+	for i := 0; i < len(p); {
+		n := cmdPoints(PathCommand(p[i]))
+		for j := i + 1; j < i+1+2*n; j += 2 {
+			x, y := m.Transform(float64(p[j])/64, float64(p[j+1])/64)
+			p[j], p[j+1] = ToFixed(x), ToFixed(y)
+		}
+		i += 1 + 2*n
+	}
+}
+
+// ToPathF returns the path converted to floating point coordinates
+func (p Path) ToPathF() PathF {
+	q := make(PathF, len(p))
+	for i := 0; i < len(p); {
+		q[i] = float64(p[i])
+		n := cmdPoints(PathCommand(p[i]))
+		for j := i + 1; j < i+1+2*n; j++ {
+			q[j] = float64(p[j]) / 64
+		}
+		i += 1 + 2*n
+	}
+	return q
+}
+
+// ToPath returns the path converted to fixed point coordinates, with each
+// value rounded to the nearest fixed point value
+func (p PathF) ToPath() Path {
+	q := make(Path, len(p))
+	for i := 0; i < len(p); {
+		q[i] = fixed.Int26_6(p[i])
+		n := cmdPoints(PathCommand(p[i]))
+		for j := i + 1; j < i+1+2*n; j++ {
+			q[j] = ToFixed(p[j])
+		}
+		i += 1 + 2*n
+	}
+	return q
+}
+
+// Transform returns a copy of the path with all of its points transformed by m
+func (p PathF) Transform(m Matrix2D) PathF {
+	q := make(PathF, len(p))
+	copy(q, p)
+	q.TransformInPlace(m)
+	return q
+}
+
+// TransformInPlace transforms all of the points of the path by m
+func (p PathF) TransformInPlace(m Matrix2D) {
+	for i := 0; i < len(p); {
+		n := cmdPoints(PathCommand(p[i]))
+		for j := i + 1; j < i+1+2*n; j += 2 {
+			p[j], p[j+1] = m.Transform(p[j], p[j+1])
+		}
+		i += 1 + 2*n
+	}
+}
+
+// Clear zeros the path slice
+func (p *PathF) Clear() {
+	*p = (*p)[:0]
+}
+
+// MoveTo starts a new subpath at (x, y)
+func (p *PathF) MoveTo(x, y float64) {
+	*p = append(*p, float64(PathMoveTo), x, y)
+}
+
+// LineTo adds a linear segment to (x, y)
+func (p *PathF) LineTo(x, y float64) {
+	*p = append(*p, float64(PathLineTo), x, y)
+}
+
+// QuadTo adds a quadratic segment with control point (bx, by) ending at (cx, cy)
+func (p *PathF) QuadTo(bx, by, cx, cy float64) {
+	*p = append(*p, float64(PathQuadTo), bx, by, cx, cy)
+}
+
+// CubeTo adds a cubic segment with control points (bx, by) and (cx, cy)
+// ending at (dx, dy)
+func (p *PathF) CubeTo(bx, by, cx, cy, dx, dy float64) {
+	*p = append(*p, float64(PathCubicTo), bx, by, cx, cy, dx, dy)
+}
+
+// Close closes the current subpath
+func (p *PathF) Close() {
+	*p = append(*p, float64(PathClose))
+}
+
+// Start starts a new curve at the given point. With Line, QuadBezier,
+// CubeBezier and Stop, it makes PathF an Adder.
+func (p *PathF) Start(a fixed.Point26_6) {
+	p.MoveTo(float64(a.X)/64, float64(a.Y)/64)
+}
+
+// Line adds a linear segment to the current curve.
+func (p *PathF) Line(b fixed.Point26_6) {
+	p.LineTo(float64(b.X)/64, float64(b.Y)/64)
+}
+
+// QuadBezier adds a quadratic segment to the current curve.
+func (p *PathF) QuadBezier(b, c fixed.Point26_6) {
+	p.QuadTo(float64(b.X)/64, float64(b.Y)/64, float64(c.X)/64, float64(c.Y)/64)
+}
+
+// CubeBezier adds a cubic segment to the current curve.
+func (p *PathF) CubeBezier(b, c, d fixed.Point26_6) {
+	p.CubeTo(float64(b.X)/64, float64(b.Y)/64, float64(c.X)/64, float64(c.Y)/64,
+		float64(d.X)/64, float64(d.Y)/64)
+}
+
+// Stop joins the ends of the path
+func (p *PathF) Stop(closeLoop bool) {
+	if closeLoop {
+		p.Close()
+	}
+}
+
+// AddTo adds the PathF p to q, rounding each point to the nearest fixed
+// point value.
+func (p PathF) AddTo(q Adder) {
+	p.ToPath().AddTo(q)
+}
+
+// String returns a readable representation of a PathF.
+func (p PathF) String() string {
+	var b strings.Builder
+	for i := 0; i < len(p); {
+		if i != 0 {
+			b.WriteByte(' ')
+		}
+		c := PathCommand(p[i])
+		n := cmdPoints(c)
+		b.WriteByte("MLQCZ"[c])
+		for j := i + 1; j < i+1+2*n; j++ {
+			if j != i+1 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%4.3f", p[j])
+		}
+		i += 1 + 2*n
+	}
+	return b.String()
+}
@@ -0,0 +1,335 @@
+// Gradients along and across the stroke of a path
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"image/color"
+	"math"
+	"sort"
+)
+
+type (
+	// PathGradientMode is the direction in which a PathGradient runs
+	PathGradientMode uint8
+
+	// PathGradient paints the stroke of a path with a gradient that follows
+	// the path instead of a line or circle. Each pixel is colored from the
+	// point of the path nearest to it, so the color is continuous through
+	// joins, caps and the gaps of dashes, however the path is stroked. Where
+	// the strokes of parts of the path that are far apart along it overlap,
+	// each pixel takes the color of the nearer part. The Path must be the
+	// same path that is stroked, and Width its stroke width.
+	PathGradient struct {
+		Path   Path
+		Stops  []GradStop
+		Spread SpreadMethod
+		Mode   PathGradientMode
+		// Width is the width of the stroke. An AcrossPath gradient runs from
+		// one of its edges to the other, and where the path turns the
+		// gradient is blended over this distance to hide the seam between
+		// segments. Zero is one pixel.
+		Width float64
+		// Length is the arc length over which offsets from 0 to 1 run along
+		// the path. Zero is the length of the whole path.
+		Length float64
+		// Value, if not nil, replaces the arc length fraction along the path
+		// with its value at the parameter t of the segment with index seg in
+		// the slice returned by Path.Segments. It should be continuous where
+		// segments meet for a smooth gradient.
+		Value func(seg int, t float64) float64
+	}
+
+	// pathGradCand is a segment within the blend distance of a point, with
+	// the parameter and distance of its nearest point
+	pathGradCand struct {
+		seg  int
+		t, d float64
+	}
+
+	// segGrid is a uniform grid of cells over the segments of a path that
+	// lists the segments whose hulls overlap each cell, for finding those
+	// near a point without testing all of them
+	segGrid struct {
+		min    Point
+		cell   float64
+		nx, ny int
+		cells  [][]int
+	}
+)
+
+// PathGradientMode constants
+const (
+	// AlongPath runs the gradient along the length of the path, from its
+	// start to its end
+	AlongPath PathGradientMode = iota
+	// AcrossPath runs the gradient across the width of the stroke, from the
+	// edge on the left of the direction of the path, as seen with y pointing
+	// down, to the edge on its right
+	AcrossPath
+)
+
+// newSegGrid returns a grid with about one cell per segment over the hulls
+// of segs. Degenerate segments are left out.
+func newSegGrid(segs []segment) *segGrid {
+	g := &segGrid{min: Point{math.Inf(1), math.Inf(1)}}
+	max := Point{math.Inf(-1), math.Inf(-1)}
+	for _, s := range segs {
+		lo, hi := s.hull()
+		g.min = Point{math.Min(g.min.X, lo.X), math.Min(g.min.Y, lo.Y)}
+		max = Point{math.Max(max.X, hi.X), math.Max(max.Y, hi.Y)}
+	}
+	side := math.Ceil(math.Sqrt(float64(len(segs))))
+	g.cell = math.Max(max.X-g.min.X, max.Y-g.min.Y) / side
+	if !(g.cell > 0) {
+		g.cell = 1
+	}
+	g.nx = int((max.X-g.min.X)/g.cell) + 1
+	g.ny = int((max.Y-g.min.Y)/g.cell) + 1
+	g.cells = make([][]int, g.nx*g.ny)
+	for i, s := range segs {
+		if s.degenerate() {
+			continue
+		}
+		lo, hi := s.hull()
+		i0, j0 := g.cellOf(lo)
+		i1, j1 := g.cellOf(hi)
+		for j := j0; j <= j1; j++ {
+			for k := i0; k <= i1; k++ {
+				g.cells[j*g.nx+k] = append(g.cells[j*g.nx+k], i)
+			}
+		}
+	}
+	return g
+}
+
+// cellOf returns the column and row of the cell containing q, clamped to
+// the grid
+func (g *segGrid) cellOf(q Point) (i, j int) {
+	clamp := func(v float64, n int) int {
+		c := int(math.Floor(v / g.cell))
+		if c < 0 {
+			return 0
+		}
+		if c >= n {
+			return n - 1
+		}
+		return c
+	}
+	return clamp(q.X-g.min.X, g.nx), clamp(q.Y-g.min.Y, g.ny)
+}
+
+// near returns the segments of segs whose nearest points to q are within
+// blend of the nearest of all of them. The cells are searched in rings
+// around q until no segment outside the rings can be that close.
+func (g *segGrid) near(segs []segment, q Point, blend float64) (cands []pathGradCand) {
+	ci, cj := g.cellOf(q)
+	best := math.Inf(1)
+	var seen []int
+	for r := 0; ; r++ {
+		i0, i1, j0, j1 := ci-r, ci+r, cj-r, cj+r
+		for j := j0; j <= j1; j++ {
+			if j < 0 || j >= g.ny {
+				continue
+			}
+			for i := i0; i <= i1; i++ {
+				if i < 0 || i >= g.nx || (j != j0 && j != j1 && i != i0 && i != i1) {
+					continue
+				}
+			cell:
+				for _, k := range g.cells[j*g.nx+i] {
+					for _, v := range seen {
+						if v == k {
+							continue cell
+						}
+					}
+					seen = append(seen, k)
+					s := segs[k]
+					if lo, hi := s.hull(); math.Sqrt(boxDist2(q, lo, hi)) >= best+blend {
+						continue
+					}
+					t, d2 := s.nearest(q)
+					d := math.Sqrt(d2)
+					best = math.Min(best, d)
+					cands = append(cands, pathGradCand{seg: k, t: t, d: d})
+				}
+			}
+		}
+		// bound is the least distance from q to a cell outside the rings
+		bound := math.Inf(1)
+		if i0 > 0 {
+			bound = math.Min(bound, q.X-(g.min.X+float64(i0)*g.cell))
+		}
+		if i1 < g.nx-1 {
+			bound = math.Min(bound, g.min.X+float64(i1+1)*g.cell-q.X)
+		}
+		if j0 > 0 {
+			bound = math.Min(bound, q.Y-(g.min.Y+float64(j0)*g.cell))
+		}
+		if j1 < g.ny-1 {
+			bound = math.Min(bound, g.min.Y+float64(j1+1)*g.cell-q.Y)
+		}
+		if best+blend <= bound {
+			break
+		}
+	}
+	n := 0
+	for _, c := range cands {
+		if c.d < best+blend {
+			cands[n] = c
+			n++
+		}
+	}
+	return cands[:n]
+}
+
+// segNeighbors returns the indices of the segments before and after each
+// segment of sps, numbered in order through all of the subpaths, or -1 at
+// the ends of open subpaths. Degenerate segments are passed over. The ends
+// of closed subpaths are joined if wrap is true.
+func segNeighbors(sps []subpath, wrap bool) (prev, next []int) {
+	base := 0
+	for _, sp := range sps {
+		var idx []int // the indices of the segments that are not degenerate
+		for i, s := range sp.segs {
+			prev, next = append(prev, -1), append(next, -1)
+			if !s.degenerate() {
+				idx = append(idx, base+i)
+			}
+		}
+		for i := 1; i < len(idx); i++ {
+			next[idx[i-1]], prev[idx[i]] = idx[i], idx[i-1]
+		}
+		if n := len(idx); wrap && sp.closed && n > 1 {
+			next[idx[n-1]], prev[idx[0]] = idx[0], idx[n-1]
+		}
+		base += len(sp.segs)
+	}
+	return
+}
+
+// chained returns true if the segment seg is one of the candidates in chain
+func chained(chain []pathGradCand, seg int) bool {
+	for _, c := range chain {
+		if c.seg == seg {
+			return true
+		}
+	}
+	return false
+}
+
+// GetColorFunction returns the color function of the gradient
+func (pg *PathGradient) GetColorFunction(opacity float64) interface{} {
+	return pg.GetColorFunctionUS(opacity, Identity)
+}
+
+// GetColorFunctionUS returns the color function of the gradient for the
+// path drawn with the transform objMatrix. Width and Length are in the units
+// of the path, and are scaled by the transform.
+func (pg *PathGradient) GetColorFunctionUS(opacity float64, objMatrix Matrix2D) interface{} {
+	switch len(pg.Stops) {
+	case 0:
+		return ApplyOpacity(color.RGBA{0, 0, 0, 255}, opacity) // default error color for gradient w/o stops.
+	case 1:
+		return ApplyOpacity(pg.Stops[0].StopColor, pg.Stops[0].Opacity*opacity)
+	}
+	g := &Gradient{Stops: append([]GradStop(nil), pg.Stops...), Spread: pg.Spread}
+	sort.Slice(g.Stops, func(i, j int) bool {
+		return g.Stops[i].Offset < g.Stops[j].Offset
+	})
+	sps := pg.Path.Transform(objMatrix).subpaths()
+	var segs []segment
+	for _, sp := range sps {
+		segs = append(segs, sp.segs...)
+	}
+	if len(segs) == 0 {
+		return ApplyOpacity(g.Stops[0].StopColor, g.Stops[0].Opacity*opacity)
+	}
+	scale := math.Sqrt(math.Abs(objMatrix.A*objMatrix.D - objMatrix.B*objMatrix.C))
+	width := pg.Width * scale
+	blend := width
+	if blend <= 0 {
+		blend = 1
+	}
+	cum := make([]float64, len(segs)+1) // arc length to the start of each segment
+	for i, s := range segs {
+		cum[i+1] = cum[i] + s.length()
+	}
+	length := pg.Length * scale
+	if length <= 0 {
+		length = cum[len(segs)]
+	}
+	// The arc length jumps where a closed subpath starts, so its ends are
+	// only blended across it
+	prev, next := segNeighbors(sps, pg.Mode == AcrossPath)
+	grid := newSegGrid(segs)
+	return ColorFunc(func(xi, yi int) color.Color {
+		q := Point{float64(xi) + 0.5, float64(yi) + 0.5}
+		cands := grid.near(segs, q, blend)
+		if len(cands) == 0 {
+			return g.tColor(0, opacity)
+		}
+		// Blend the nearest candidate with those joined to it along the path
+		// by weights that fall to zero at the blend distance beyond the
+		// nearest, so the color is continuous where the nearest segment
+		// changes. The weight of a candidate is the least weight of those
+		// between it and the nearest, so that it falls to zero before the
+		// chain of them breaks. Other parts of the path that pass near are
+		// not blended, since their colors are unrelated.
+		nearest := 0
+		for i, c := range cands {
+			if c.d < cands[nearest].d {
+				nearest = i
+			}
+		}
+		dmin := cands[nearest].d
+		weight := func(seg int) (int, float64) {
+			for i, c := range cands {
+				if c.seg == seg {
+					return i, 1 - (c.d-dmin)/blend
+				}
+			}
+			return -1, 0
+		}
+		chain := []pathGradCand{cands[nearest]}
+		ws := []float64{1}
+		for _, step := range [][]int{next, prev} {
+			cw := 1.0
+			for k := step[cands[nearest].seg]; k >= 0; k = step[k] {
+				i, w := weight(k)
+				if cw = math.Min(cw, w); cw <= 0 || chained(chain, k) {
+					break // the chain is broken, or has come around a closed subpath
+				}
+				chain = append(chain, cands[i])
+				ws = append(ws, cw)
+			}
+		}
+		var sum, wsum float64
+		for j, c := range chain {
+			w := ws[j]
+			s := segs[c.seg]
+			var v float64
+			switch {
+			case pg.Mode == AcrossPath:
+				v = s.tangent(c.t).Unit().Cross(q.Sub(s.eval(c.t)))
+			case pg.Value != nil:
+				v = pg.Value(c.seg, c.t)
+			default:
+				v = cum[c.seg] + s.lengthTo(c.t)
+			}
+			sum += w * v
+			wsum += w
+		}
+		v := sum / wsum
+		switch {
+		case pg.Mode == AcrossPath:
+			if width <= 0 {
+				return g.tColor(0.5, opacity)
+			}
+			return g.tColor(v/width+0.5, opacity)
+		case pg.Value != nil:
+			return g.tColor(v, opacity)
+		}
+		return g.tColor(v/length, opacity)
+	})
+}
@@ -0,0 +1,143 @@
+// Elliptical and calligraphic pens
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"math"
+)
+
+// penTol is the flattening tolerance in pixels of a nib pen when the
+// stroker has none set
+const penTol = 0.1
+
+type (
+	// PenShape is the shape of the pen that a Stroker sweeps along a path
+	PenShape uint8
+
+	// Pen describes the shape of the pen of a stroke. The width set by
+	// SetStroke is the size of the pen along its Angle, in radians.
+	Pen struct {
+		Shape PenShape
+		// Ratio is the width of an EllipsePen across its Angle as a
+		// fraction of its width along it.
+		Ratio float64
+		Angle float64
+	}
+)
+
+// PenShape constants
+const (
+	// RoundPen is the circular pen of ordinary strokes, which draws the
+	// joins set by SetStroke
+	RoundPen PenShape = iota
+	// EllipsePen is an ellipse, so the stroke is thickest where the path
+	// runs across the Angle of the pen, and thinnest along it.
+	EllipsePen
+	// NibPen is a flat calligraphic nib, a line the width of the stroke at
+	// the Angle of the pen, so the stroke is as wide as the width across
+	// the nib and vanishes where the path runs along it.
+	NibPen
+)
+
+// SetPen sets the shape of the pen that is swept along the path. The outline
+// is the exact sweep of the pen, up to the flattening of curves. An
+// EllipsePen is the round pen stroked in the space where the ellipse is a
+// circle, so its caps and joins are those of SetStroke mapped back by the
+// ellipse. A NibPen has no joins, and its caps are drawn on the ends of the
+// nib. Dashes of a Dasher are measured along the path before the pen is
+// swept. Width profiles and alignment do not apply to shaped pens. The pen
+// is swept along whole subpaths, so each is held until Stop and only then
+// drawn; stop every subpath before calling Draw.
+func (r *Stroker) SetPen(p Pen) {
+	r.pen = p
+	r.w.buf.Clear()
+}
+
+// Pen returns the pen set by SetPen
+func (r *Stroker) Pen() Pen {
+	return r.pen
+}
+
+// strokePen sweeps the shaped pen along the collected subpath, or its dashes
+// if q is a Dasher with dashes
+func (r *Stroker) strokePen(q Adder) {
+	p := r.w.buf
+	if d, ok := q.(*Dasher); ok && len(d.Dashes) > 0 {
+		p = p.dash(d.Dashes, d.DashOffset)
+	}
+	if r.pen.Shape == NibPen {
+		r.sweepNib(p)
+		return
+	}
+	ratio := r.pen.Ratio
+	if ratio <= 0 {
+		ratio = 1
+	}
+	m := Identity.Rotate(r.pen.Angle).Scale(1, ratio)
+	tol, fn := r.tol, r.w.fn
+	if tol > 0 {
+		r.SetToleranceFor(tol, m)
+	}
+	r.w.fn = nil
+	o := r.outline(r, p.Transform(m.Invert()))
+	r.tol, r.w.fn = tol, fn
+	o.TransformInPlace(m)
+	r.addOutline(o)
+}
+
+// sweepNib sends the sweep of the nib along each subpath of p to the sink.
+// The sweep of a line along a polyline is the union of the parallelograms
+// swept along each of its lines, which are all sent with the same
+// orientation so that they merge by the nonzero winding rule.
+func (r *Stroker) sweepNib(p Path) {
+	tol := r.tol
+	if tol <= 0 {
+		tol = penTol
+	}
+	sa, ca := math.Sincos(r.pen.Angle)
+	h := Point{ca, sa}.Mul(float64(r.u) / 64)
+	ra := r.sink()
+	for _, sp := range p.subpaths() {
+		poly := sp.polyline(tol)
+		for i := 1; i < len(poly); i++ {
+			a, b := poly[i-1], poly[i]
+			if a == b {
+				continue
+			}
+			k := h
+			if b.Sub(a).Cross(h) < 0 {
+				k = h.Mul(-1)
+			}
+			ra.Start(a.Add(k).Fixed())
+			ra.Line(a.Sub(k).Fixed())
+			ra.Line(b.Sub(k).Fixed())
+			ra.Line(b.Add(k).Fixed())
+			ra.Line(a.Add(k).Fixed())
+		}
+		if sp.closed || len(poly) < 2 {
+			continue
+		}
+		r.nibCap(ra, r.CapT, poly[0], poly[0].Sub(poly[1]), h)
+		n := len(poly)
+		r.nibCap(ra, r.CapL, poly[n-1], poly[n-1].Sub(poly[n-2]), h)
+	}
+}
+
+// nibCap draws the cap c on the nib h at the end point a of a line that
+// leaves the stroke in the direction out. The normal is the nib, pointing
+// so that the cap is drawn outward. No cap is drawn if the line runs along
+// the nib. The cap is closed along the nib, since it is not joined to the
+// sides of the stroke.
+func (r *Stroker) nibCap(ra Adder, c CapFunc, a, out, h Point) {
+	if out.Len() == 0 || math.Abs(out.Unit().Cross(h)) < 1e-9*h.Len() {
+		return
+	}
+	eNorm := h
+	// the cap goes out in the starboard direction of its normal
+	if (Point{-h.Y, h.X}).Dot(out) < 0 {
+		eNorm = h.Mul(-1)
+	}
+	c(ra, a.Fixed(), eNorm.Fixed())
+	ra.Line(a.Add(eNorm).Fixed()) // close the cap along the end of the nib
+}
@@ -329,6 +329,25 @@ func TestGeom(t *testing.T) {
 	}
 }
 
+// TestRadCurvature checks the radius of curvature that the Arc and ArcClip
+// joins are drawn from, which is |a|²·dm/|h| for the rejection h of the
+// second leg a of the control polygon from the first.
+func TestRadCurvature(t *testing.T) {
+	for _, c := range []struct {
+		p2   fixed.Point26_6
+		dm   fixed.Int52_12
+		want fixed.Int26_6
+	}{
+		{ToFixedP(20, 10), fixed.Int52_12(3<<12) / 2, -30 * 64},
+		{ToFixedP(20, -10), fixed.Int52_12(2 << 12), 40 * 64},
+		{ToFixedP(20, 0), fixed.Int52_12(2 << 12), 0},
+	} {
+		if r := RadCurvature(ToFixedP(0, 0), ToFixedP(10, 0), c.p2, c.dm); r != c.want {
+			t.Error("wrong radius of curvature", c.p2, r, c.want)
+		}
+	}
+}
+
 func TestToLength(t *testing.T) {
         p := fixed.Point26_6{X: 2, Y: -2}
         ln := fixed.I(40)
@@ -654,6 +673,98 @@ func TestGradient(t *testing.T) {
 	}
 }
 
+func TestPathGradient(t *testing.T) {
+	const wx, wy = 300, 300
+	var p Path // a right angle turn
+	p.Start(ToFixedP(50, 50))
+	p.Line(ToFixedP(250, 50))
+	p.Line(ToFixedP(250, 250))
+	stops := []GradStop{
+		GradStop{StopColor: color.RGBA{255, 0, 0, 255}, Offset: 0, Opacity: 1},
+		GradStop{StopColor: color.RGBA{0, 0, 255, 255}, Offset: 1, Opacity: 1},
+	}
+	red := func(c color.Color) float64 {
+		r, _, _, _ := c.RGBA()
+		return float64(r) / 0xffff
+	}
+	along := (&PathGradient{Path: p, Stops: stops, Width: 20}).GetColorFunction(1).(ColorFunc)
+	for _, c := range []struct {
+		x, y int
+		want float64
+	}{{50, 50, 1}, {150, 45, 0.75}, {250, 150, 0.25}, {250, 250, 0}} {
+		if r := red(along(c.x, c.y)); math.Abs(r-c.want) > 0.02 {
+			t.Error("wrong color along path at", c.x, c.y, r, c.want)
+		}
+	}
+	across := (&PathGradient{Path: p, Stops: stops, Width: 20, Mode: AcrossPath}).GetColorFunction(1).(ColorFunc)
+	if r := red(across(150, 40)); r < 0.9 {
+		t.Error("left edge should be red", r)
+	}
+	if r := red(across(150, 59)); r > 0.1 {
+		t.Error("right edge should be blue", r)
+	}
+	if r := red(across(260, 150)); r < 0.9 {
+		t.Error("left edge after the turn should be red", r)
+	}
+	value := (&PathGradient{Path: p, Stops: stops, Width: 20,
+		Value: func(seg int, t float64) float64 { return float64(seg) }}).GetColorFunction(1).(ColorFunc)
+	if r := red(value(150, 50)); r != 1 {
+		t.Error("wrong color for segment value", r)
+	}
+
+	// Parts of the path that are near in space but far along it are not
+	// blended, even where their strokes overlap
+	for _, gap := range []float64{30, 15} {
+		var pin Path
+		pin.Start(ToFixedP(0, 50))
+		pin.Line(ToFixedP(200, 50))
+		pin.Line(ToFixedP(200, 50+gap))
+		pin.Line(ToFixedP(0, 50+gap))
+		f := (&PathGradient{Path: pin, Stops: stops, Width: 20}).GetColorFunction(1).(ColorFunc)
+		for _, y := range []int{53, 59} {
+			if float64(y) > 50+gap/2 {
+				continue
+			}
+			if r, want := red(f(20, y)), 1-20.5/(400+gap); math.Abs(r-want) > 0.01 {
+				t.Error("hairpin leg blended with the other leg", gap, y, r, want)
+			}
+		}
+	}
+
+	// Neighboring pixels of the dashed stroke differ by little, so there are
+	// no seams at the join or the ends of the dashes
+	img := image.NewRGBA(image.Rect(0, 0, wx, wy))
+	scannerGV := NewScannerGV(wx, wy, img, img.Bounds())
+	d := NewDasher(wx, wy, scannerGV)
+	d.SetStroke(20*64, 4*64, RoundCap, nil, RoundGap, Round, []float64{60, 15}, 0)
+	for _, mode := range []PathGradientMode{AlongPath, AcrossPath} {
+		d.SetColor((&PathGradient{Path: p, Stops: stops, Width: 20, Mode: mode}).GetColorFunction(1))
+		p.AddTo(d)
+		d.Draw()
+		d.Clear()
+		max := 0.0
+		for y := 0; y < wy-1; y++ {
+			for x := 0; x < wx-1; x++ {
+				c := img.RGBAAt(x, y)
+				if c.A != 255 {
+					continue
+				}
+				for _, n := range []color.RGBA{img.RGBAAt(x+1, y), img.RGBAAt(x, y+1)} {
+					if n.A == 255 {
+						max = math.Max(max, math.Abs(float64(c.R)-float64(n.R))/255)
+					}
+				}
+			}
+		}
+		if limit := []float64{0.01, 0.1}[mode]; max > limit {
+			t.Error("seam in path gradient", mode, max)
+		}
+		if err := SaveToPngFile([]string{"testdata/pathGradAlong.png", "testdata/pathGradAcross.png"}[mode], img); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
 // TestMultiFunction tests a Dasher's ability to function
 // as a filler, stroker, and dasher by invoking the corresponding anonymous structs
 func TestMultiFunctionGV(t *testing.T) {
@@ -42,6 +42,7 @@ type (
 		Source                 image.Image
 		Offset                 image.Point
 		minX, minY, maxX, maxY fixed.Int26_6 // keep track of bounds
+		opacity                float64       // scales the opacity of Source if less than 1, see setOpacity
 	}
 )
 
@@ -133,12 +134,30 @@ func (s *ScannerGV) Line(b fixed.Point26_6) {
 	s.r.LineTo(float32(b.X)/64, float32(b.Y)/64)
 }
 
+// setOpacity scales the opacity of the color that the scanner draws with by
+// k, however the color is set. A k of 1 or more draws the color as set.
+func (s *ScannerGV) setOpacity(k float64) {
+	s.opacity = k
+}
+
+// source returns Source with its opacity scaled by the opacity of the
+// scanner
+func (s *ScannerGV) source() image.Image {
+	if s.opacity <= 0 || s.opacity >= 1 {
+		return s.Source
+	}
+	if u, ok := s.Source.(*image.Uniform); ok {
+		return image.NewUniform(scaleColor(u.C, s.opacity))
+	}
+	return opacityImage{s.Source, s.opacity}
+}
+
 // Draw renders the accumulate scan to the desination
 func (s *ScannerGV) Draw() {
 	// This draws the entire bounds of the image, because
 	// at this point the alpha mask does not shift with the
 	// placement of the target rectangle in the vector rasterizer
-	s.r.Draw(s.Dest, s.Dest.Bounds(), s.Source, s.Offset)
+	s.r.Draw(s.Dest, s.Dest.Bounds(), s.source(), s.Offset)
 
 	// Remove the line above and uncomment the lines below if you
 	// are using a version of the vector rasterizer that shifts the alpha
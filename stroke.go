@@ -49,6 +49,18 @@ type (
 
 		JoinMode JoinMode
 		inStroke bool
+		out      Adder      // when not nil, receives the stroke outline instead of the Filler
+		w        widthState // width profile, see SetWidthProfile
+		align    StrokeAlign
+		pen      Pen
+
+		overlapFree bool
+		merged      *outlineCollector // the stroke outline collected for an overlap free stroke
+
+		zeroLen  bool // no point of the subpath so far is away from its start
+		hairline bool
+		width    fixed.Int26_6 // the width set by SetStroke
+		color    interface{}   // the color set by SetColor before any hairline opacity
 	}
 )
 
@@ -88,14 +100,16 @@ func NewStroker(width, height int, scanner Scanner) *Stroker {
 // value for miter, arc, miterclip and arcClip joinModes. CapL and CapT are the capping functions for leading and trailing
 // line ends. If one is nil, the other function is used at both ends. If both are nil, both ends are ButtCapped.
 // gp is the gap function that determines how a gap on the convex side of two joining lines is filled. jm is the JoinMode
-// for curve segments.
+// for curve segments. The stroke is centered on the path; SetAlignment, called after SetStroke, moves it to one side.
 func (r *Stroker) SetStroke(width, miterLimit fixed.Int26_6, capL, capT CapFunc, gp GapFunc, jm JoinMode) {
-	r.u = width / 2
+	r.width = width
 	r.CapL = capL
 	r.CapT = capT
 	r.JoinMode = jm
 	r.JoinGap = gp
-	r.mLimit = (r.u * miterLimit) >> 6
+	r.w.miterRate = miterLimit
+	r.SetAlignment(AlignCenter)
+	r.applyHairline()
 
 	if r.CapT == nil {
 		if r.CapL == nil {
@@ -241,10 +255,10 @@ func (r *Stroker) Joiner(p C2Point) {
 // the top and bottom edges. This function encodes most of the logic of how to
 // handle joins between the given C2Point point p, and the end of the line.
 func (r *Stroker) strokeEdge(p C2Point, crossProd fixed.Int26_6) {
-	ra := &r.Filler
+	ra := r.sink()
 	s1, s2 := p.P.Add(p.TNorm), p.P.Add(p.LNorm) // Bevel points for top leading and trailing
 	ra.Start(s1)
-	if crossProd > -epsilonFixed*epsilonFixed { // Almost co-linear or convex
+	if crossProd > -epsilonFixed*epsilonFixed || r.slightTurn(crossProd) { // Almost co-linear or convex
 		ra.Line(s2)
 		return // No need to fill any gaps
 	}
@@ -320,7 +334,7 @@ func (r *Stroker) strokeEdge(p C2Point, crossProd fixed.Int26_6) {
 		switch {
 		case rt == 0: // rl != 0, because one must be non-zero as checked above
 			xt, intersect := RayCircleIntersection(s1.Add(p.TTan), s1, cl, rl)
-			if intersect {
+			if intersect && arcsMeet(p, s1, s2, xt) {
 				ray1, ray2 := xt.Sub(cl), s2.Sub(cl)
 				clockwise := (ray1.X*ray2.Y > ray1.Y*ray2.X) // Sign of xprod
 				if Length(p.P.Sub(xt)) < r.mLimit {          // within miter limit
@@ -365,7 +379,7 @@ func (r *Stroker) strokeEdge(p C2Point, crossProd fixed.Int26_6) {
 			}
 		case rl == 0: // rt != 0, because one must be non-zero as checked above
 			xt, intersect := RayCircleIntersection(s2.Sub(p.LTan), s2, ct, rt)
-			if intersect {
+			if intersect && arcsMeet(p, s1, s2, xt) {
 				ray1, ray2 := s1.Sub(ct), xt.Sub(ct)
 				clockwise := ray1.X*ray2.Y > ray1.Y*ray2.X
 				if Length(p.P.Sub(xt)) < r.mLimit { // within miter limit
@@ -407,7 +421,7 @@ func (r *Stroker) strokeEdge(p C2Point, crossProd fixed.Int26_6) {
 		default: // Both rl != 0 and rt != 0 as checked above
 			xt1, xt2, gIntersect := CircleCircleIntersection(ct, cl, rt, rl)
 			xt, intersect := ClosestPortside(s1, s2, xt1, xt2, gIntersect)
-			if intersect {
+			if intersect && arcsMeet(p, s1, s2, xt) {
 				ray1, ray2 := s1.Sub(ct), xt.Sub(ct)
 				clockwiseT := (ray1.X*ray2.Y > ray1.Y*ray2.X)
 				ray1, ray2 = xt.Sub(cl), s2.Sub(cl)
@@ -429,7 +443,7 @@ func (r *Stroker) strokeEdge(p C2Point, crossProd fixed.Int26_6) {
 						// t is the fraction to scale the line or arc from the bevel point
 						// to the line intersection, so that they abbut the miter limit line.
 						t := fixed.Int26_6(1<<tStrokeShift) - ((r.mLimit - projLen) << tStrokeShift / (Length(xa) - projLen))
-						_, _, ps1, ds1 := strokeArc(ra, ct, s1, xt, clockwiseT, 0, t, r.Filler.Line)
+						_, _, ps1, ds1 := strokeArc(ra, ct, s1, xt, clockwiseT, 0, t, ra.Line)
 						ps2, ds2, fs2, _ := strokeArc(ra, cl, xt, s2, clockwiseL, t, 0, ra.Start)
 						midP := ps1.Add(ps2).Mul(fixed.Int26_6(1 << 5)) // midpoint
 						midLine := turnStarboard90(midP.Sub(ps1))
@@ -460,19 +474,46 @@ func (r *Stroker) strokeEdge(p C2Point, crossProd fixed.Int26_6) {
 	return
 }
 
+// slightTurn reports if the edges of a join with the cross product crossProd
+// of its normals turn so little that any join would stand less than a quarter
+// of a fixed point unit beyond the bevel. A turn of θ radians puts a join
+// about u*θ²/8 beyond it, while the gap functions and the circles of
+// curvature of arc joins become ill-conditioned, and can draw flaps that
+// overlap the stroke rather than fill the sliver of a gap.
+func (r *Stroker) slightTurn(crossProd fixed.Int26_6) bool {
+	u := float64(r.u)
+	theta := float64(-crossProd) / (u * u)
+	return u*theta*theta < 2
+}
+
+// arcsMeet reports if the point xt, where the extensions of the trailing and
+// leading edges of an arc join meet, lies ahead of the trailing bevel point s1
+// and behind the leading bevel point s2. It does not where the circles of
+// curvature nearly coincide, as at the joins of a circle made of curves, and
+// their intersection falls anywhere on them; the arcs would then run along
+// the stroke edge and back, in a flap that overlaps the stroke.
+func arcsMeet(p C2Point, s1, s2, xt fixed.Point26_6) bool {
+	return DotProd(xt.Sub(s1), p.TTan) > 0 && DotProd(s2.Sub(xt), p.LTan) > 0
+}
+
 // Stop a stroked line. The line will close
 // is isClosed is true. Otherwise end caps will
 // be drawn at both ends.
 func (r *Stroker) Stop(isClosed bool) {
+	if r.buffering() {
+		r.strokeBuffered(r, isClosed)
+		return
+	}
+	isClosed = r.stopZeroLength(r, isClosed)
 	if r.inStroke == false {
 		return
 	}
-	rf := &r.Filler
+	rf := r.sink()
 	if isClosed {
-		if r.firstP.P != rf.a {
+		if r.firstP.P != r.a {
 			r.Line(r.firstP.P)
 		}
-		a := rf.a
+		a := r.a
 		r.firstP.TNorm = r.leadPoint.TNorm
 		r.firstP.RT = r.leadPoint.RT
 		r.firstP.TTan = r.leadPoint.TTan
@@ -484,7 +525,7 @@ func (r *Stroker) Stop(isClosed bool) {
 		r.Joiner(r.firstP)
 		r.firstP.blackWidowMark(rf)
 	} else {
-		a := rf.a
+		a := r.a
 		rf.Start(r.leadPoint.P.Sub(r.leadPoint.TNorm))
 		rf.Line(a.Sub(r.ln))
 		rf.Start(a.Add(r.ln))
@@ -495,19 +536,45 @@ func (r *Stroker) Stop(isClosed bool) {
 	r.inStroke = false
 }
 
+// stopZeroLength prepares to stop a subpath of zero length, such as M x,y Z
+// or M x,y L x,y Z, which SVG draws as the caps of a line along the x axis.
+// A closed subpath with no segments is given a line of zero length, which
+// is sent to sgm, and the subpath is capped rather than closed, so isClosed
+// is returned as false.
+func (r *Stroker) stopZeroLength(sgm Rasterx, isClosed bool) bool {
+	if !r.zeroLen {
+		return isClosed
+	}
+	r.zeroLen = false
+	if !r.inStroke && isClosed {
+		r.LineSeg(sgm, r.a)
+	}
+	return false
+}
+
 // QuadBezier starts a stroked quadratic bezier.
 func (r *Stroker) QuadBezier(b, c fixed.Point26_6) {
+	if r.buffering() {
+		r.w.buf.QuadBezier(b, c)
+		return
+	}
 	r.quadBezierf(r, b, c)
 }
 
 // CubeBezier starts a stroked quadratic bezier.
 func (r *Stroker) CubeBezier(b, c, d fixed.Point26_6) {
+	if r.buffering() {
+		r.w.buf.CubeBezier(b, c, d)
+		return
+	}
 	r.cubeBezierf(r, b, c, d)
 }
 
 // quadBezierf calcs end curvature of beziers
 func (r *Stroker) quadBezierf(s Rasterx, b, c fixed.Point26_6) {
+	r.zeroLen = r.zeroLen && b == r.first && c == r.first
 	r.trailPoint = r.leadPoint
+	r.beginSegment(r.a, b, c)
 	r.CalcEndCurvature(r.a, b, c, c, b, r.a, fixed.Int52_12(2<<12), doCalcCurvature(s))
 	r.QuadBezierF(s, b, c)
 	r.a = c
@@ -529,11 +596,13 @@ func doCalcCurvature(r Rasterx) bool {
 }
 
 func (r *Stroker) cubeBezierf(sgm Rasterx, b, c, d fixed.Point26_6) {
+	r.zeroLen = r.zeroLen && b == r.first && c == r.first && d == r.first
 	if (r.a == b && c == d) || (r.a == b && b == c) || (c == b && d == c) {
 		sgm.Line(d)
 		return
 	}
 	r.trailPoint = r.leadPoint
+	r.beginSegment(r.a, b, c, d)
 	// Only calculate curvature if stroking or and using arc or arc-clip
 	doCalcCurve := doCalcCurvature(sgm)
 	const dm = fixed.Int52_12((3 << 12) / 2)
@@ -553,11 +622,16 @@ func (r *Stroker) cubeBezierf(sgm Rasterx, b, c, d fixed.Point26_6) {
 
 // Line adds a line segment to the rasterizer
 func (r *Stroker) Line(b fixed.Point26_6) {
+	if r.buffering() {
+		r.w.buf.Line(b)
+		return
+	}
 	r.LineSeg(r, b)
 }
 
 //LineSeg is called by both the Stroker and Dasher
 func (r *Stroker) LineSeg(sgm Rasterx, b fixed.Point26_6) {
+	r.zeroLen = r.zeroLen && b == r.first
 	r.trailPoint = r.leadPoint
 	ba := b.Sub(r.a)
 	if ba.X == 0 && ba.Y == 0 { // a == b, line is degenerate
@@ -567,11 +641,11 @@ func (r *Stroker) LineSeg(sgm Rasterx, b fixed.Point26_6) {
 			ba = fixed.Point26_6{X: 1 << 6, Y: 0}
 		}
 	}
-	bnorm := turnPort90(ToLength(ba, r.u))
+	r.beginSegment(r.a, b)
 	r.trailPoint.LTan = ba
 	r.leadPoint.TTan = ba
-	r.trailPoint.LNorm = bnorm
-	r.leadPoint.TNorm = bnorm
+	r.trailPoint.LNorm = turnPort90(ToLength(ba, r.u))
+	r.leadPoint.TNorm = turnPort90(ToLength(ba, r.halfWidthAt(r.w.segEnd)))
 	r.trailPoint.RL = 0.0
 	r.leadPoint.RT = 0.0
 	r.trailPoint.P = r.a
@@ -589,13 +663,14 @@ func (r *Stroker) lineF(b fixed.Point26_6) {
 	// b is either an intra-segment value, or
 	// the end of the segment.
 	var bnorm fixed.Point26_6
-	a := r.a                // Hold a since r.a is going to change during stroke operation
+	a := r.a // Hold a since r.a is going to change during stroke operation
+	r.walkTo(a, b)
 	if b == r.leadPoint.P { // End of segment
 		bnorm = r.leadPoint.TNorm // Use more accurate leadPoint tangent
 	} else {
 		bnorm = turnPort90(ToLength(b.Sub(a), r.u)) // Intra segment normal
 	}
-	ra := &r.Filler
+	ra := r.sink()
 	ra.Start(b.Sub(bnorm))
 	ra.Line(a.Sub(r.ln))
 	ra.Start(a.Add(r.ln))
@@ -606,10 +681,44 @@ func (r *Stroker) lineF(b fixed.Point26_6) {
 
 // Start iniitates a stroked path
 func (r *Stroker) Start(a fixed.Point26_6) {
+	if r.buffering() {
+		r.bufferStart(r, a)
+		return
+	}
 	r.inStroke = false
+	r.zeroLen = true
+	r.leadPoint = C2Point{} // a zero length subpath is capped along the x axis
+	r.w.at = 0
+	if r.out != nil || r.overlapFree { // the outline is being captured, so leave the scanner alone
+		r.a, r.first = a, a
+		return
+	}
 	r.Filler.Start(a)
 }
 
+// sink returns the Adder that receives the stroke outline
+func (r *Stroker) sink() Adder {
+	if r.out != nil {
+		return r.out
+	}
+	if r.overlapFree {
+		if r.merged == nil {
+			r.merged = &outlineCollector{}
+		}
+		return r.merged
+	}
+	return &r.Filler
+}
+
+// addOutline sends the stroke outline o, captured as a Path, to the sink.
+// Capturing leaves the point of the Filler at the end of the stroked path,
+// which was never started on the scanner, so the point is reset to keep it
+// from being closed by a line when o is started.
+func (r *Stroker) addOutline(o Path) {
+	r.first = r.a
+	o.AddTo(r.sink())
+}
+
 // CalcEndCurvature calculates the radius of curvature given the control points
 // of a bezier curve.
 // It is a low level function exposed for the purposes of callbacks
@@ -621,7 +730,7 @@ func (r *Stroker) CalcEndCurvature(p0, p1, p2, q0, q1, q2 fixed.Point26_6,
 	r.trailPoint.LTan = p1.Sub(p0)
 	r.leadPoint.TTan = q0.Sub(q1)
 	r.trailPoint.LNorm = turnPort90(ToLength(r.trailPoint.LTan, r.u))
-	r.leadPoint.TNorm = turnPort90(ToLength(r.leadPoint.TTan, r.u))
+	r.leadPoint.TNorm = turnPort90(ToLength(r.leadPoint.TTan, r.halfWidthAt(r.w.segEnd)))
 	if calcRadCuve {
 		r.trailPoint.RL = RadCurvature(p0, p1, p2, dm)
 		r.leadPoint.RT = -RadCurvature(q0, q1, q2, dm)
@@ -636,7 +745,7 @@ func (r *Stroker) joinF() {
 		r.inStroke = true
 		r.firstP = r.trailPoint
 	} else {
-		ra := &r.Filler
+		ra := r.sink()
 		tl := r.trailPoint.P.Sub(r.trailPoint.TNorm)
 		th := r.trailPoint.P.Add(r.trailPoint.TNorm)
 		if r.a != r.trailPoint.P || r.ln != r.trailPoint.TNorm {
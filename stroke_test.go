@@ -0,0 +1,727 @@
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+// Created 2018 by S.R.Wiley
+package rasterx_test
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+
+	. "github.com/srwiley/rasterx"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/math/fixed"
+)
+
+// strokeAlpha strokes the path with the Adder made by mk and returns the alpha channel
+func strokeAlpha(wx, wy int, p Path, mk func(s Scanner) Adder) []uint8 {
+	img := image.NewRGBA(image.Rect(0, 0, wx, wy))
+	scannerGV := NewScannerGV(wx, wy, img, img.Bounds())
+	scannerGV.SetColor(colornames.Black)
+	a := mk(scannerGV)
+	p.AddTo(a)
+	scannerGV.Draw()
+	alpha := make([]uint8, wx*wy)
+	for i := range alpha {
+		alpha[i] = img.Pix[i*4+3]
+	}
+	return alpha
+}
+
+func TestStrokeOutline(t *testing.T) {
+	const wx, wy = 512, 512
+	p := GetTestPath()
+	s := NewStroker(wx, wy, NewScannerGV(wx, wy, image.NewRGBA(image.Rect(0, 0, 1, 1)), image.Rect(0, 0, 1, 1)))
+	s.SetStroke(10*64, 4*64, RoundCap, nil, RoundGap, ArcClip)
+	outline := s.StrokeOutline(p)
+	if !strings.Contains(outline.String(), "C") {
+		t.Error("round gaps should be kept as curves")
+	}
+	direct := strokeAlpha(wx, wy, p, func(sc Scanner) Adder {
+		st := NewStroker(wx, wy, sc)
+		st.SetStroke(10*64, 4*64, RoundCap, nil, RoundGap, ArcClip)
+		return st
+	})
+	if m, n := alphaDiff(direct, fillAlpha(wx, wy, outline)); n > 0 {
+		t.Error("stroke outline renders differently", m, n)
+	}
+
+	d := NewDasher(wx, wy, NewScannerGV(wx, wy, image.NewRGBA(image.Rect(0, 0, 1, 1)), image.Rect(0, 0, 1, 1)))
+	d.SetStroke(10*64, 4*64, RoundCap, nil, RoundGap, ArcClip, []float64{33, 12}, 0)
+	dashed := d.StrokeOutline(p)
+	directDash := strokeAlpha(wx, wy, p, func(sc Scanner) Adder {
+		dd := NewDasher(wx, wy, sc)
+		dd.SetStroke(10*64, 4*64, RoundCap, nil, RoundGap, ArcClip, []float64{33, 12}, 0)
+		return dd
+	})
+	if m, n := alphaDiff(directDash, fillAlpha(wx, wy, dashed)); n > 0 {
+		t.Error("dashed outline renders differently", m, n)
+	}
+}
+
+func TestStrokeTolerance(t *testing.T) {
+	const wx, wy = 200, 200
+	circle := getCirclePath(100, 100, 50)
+	s := NewStroker(wx, wy, NewScannerGV(wx, wy, image.NewRGBA(image.Rect(0, 0, 1, 1)), image.Rect(0, 0, 1, 1)))
+	s.SetStroke(20*64, 4*64, ButtCap, nil, FlatGap, Bevel)
+	countLines := func(p Path) (n int) {
+		return strings.Count(p.String(), "L")
+	}
+	const tol = 0.05
+	s.SetTolerance(tol)
+	fine := s.StrokeOutline(circle)
+	s.SetTolerance(2)
+	if n := countLines(s.StrokeOutline(circle)); n >= countLines(fine) {
+		t.Error("larger tolerance should remove lines", n, countLines(fine))
+	}
+	// Both edges of the stroke are within tol of the circles they approximate,
+	// allowing for fixed point rounding.
+	const eps = 4.0 / 64
+	radius := func(x, y fixed.Int26_6) float64 {
+		return math.Hypot(float64(x)/64-100, float64(y)/64-100)
+	}
+	var lx, ly fixed.Int26_6
+	for i := 0; i < len(fine); {
+		switch PathCommand(fine[i]) {
+		case PathMoveTo:
+			lx, ly = fine[i+1], fine[i+2]
+			i += 3
+		case PathLineTo:
+			x, y := fine[i+1], fine[i+2]
+			r0, r1, rm := radius(lx, ly), radius(x, y), radius((lx+x)/2, (ly+y)/2)
+			for _, r := range []float64{r0, r1} {
+				if r < 40-eps || r > 60+eps {
+					t.Error("outline point off the stroke edge", r)
+				}
+			}
+			if (r0 > 55 && r1 > 55 && rm < 60-tol-eps) || (r0 < 45 && r1 < 45 && rm < 40-tol-eps) {
+				t.Error("outline line exceeds tolerance", r0, r1, rm)
+			}
+			lx, ly = x, y
+			i += 3
+		case PathClose:
+			i++
+		default:
+			t.Error("unexpected curve in outline", fine[i])
+			return
+		}
+	}
+}
+
+func TestStrokeWidthProfile(t *testing.T) {
+	const wx, wy = 512, 512
+	// A constant profile draws the same stroke as the fixed width
+	p := GetTestPath()
+	plain := strokeAlpha(wx, wy, p, func(sc Scanner) Adder {
+		st := NewStroker(wx, wy, sc)
+		st.SetStroke(10*64, 4*64, RoundCap, nil, RoundGap, ArcClip)
+		return st
+	})
+	constant := strokeAlpha(wx, wy, p, func(sc Scanner) Adder {
+		st := NewStroker(wx, wy, sc)
+		st.SetStroke(10*64, 4*64, RoundCap, nil, RoundGap, ArcClip)
+		st.SetWidthProfile(func(float64) float64 { return 10 })
+		return st
+	})
+	if m, n := alphaDiff(plain, constant); n > 0 {
+		t.Error("constant width profile differs from plain stroke", m, n)
+	}
+
+	// A tapered line widens from 4 to 40 pixels
+	var line Path
+	line.Start(ToFixedP(20, 100))
+	line.Line(ToFixedP(220, 100))
+	line.Line(ToFixedP(420, 100))
+	line.Stop(false)
+	taper := WidthStops(WidthStop{0, 4}, WidthStop{1, 40})
+	colWidth := func(alpha []uint8, x int) (w float64) {
+		for y := 0; y < wy; y++ {
+			w += float64(alpha[y*wx+x]) / 255
+		}
+		return
+	}
+	for _, dashes := range [][]float64{nil, {30, 10}} {
+		alpha := strokeAlpha(wx, wy, line, func(sc Scanner) Adder {
+			d := NewDasher(wx, wy, sc)
+			d.SetStroke(10*64, 4*64, ButtCap, nil, nil, Miter, dashes, 0)
+			d.SetWidthProfile(taper)
+			return d
+		})
+		for _, x := range []int{25, 195, 225, 405} {
+			want := taper(float64(x-20) / 400)
+			if w := colWidth(alpha, x); math.Abs(w-want) > 1 {
+				t.Error("wrong tapered width", dashes, x, w, want)
+			}
+		}
+	}
+	// Clearing the profile returns to the width set by SetStroke
+	cleared := strokeAlpha(wx, wy, p, func(sc Scanner) Adder {
+		st := NewStroker(wx, wy, sc)
+		st.SetStroke(10*64, 4*64, RoundCap, nil, RoundGap, ArcClip)
+		st.SetWidthProfile(taper)
+		st.StrokeOutline(line)
+		st.SetWidthProfile(nil)
+		return st
+	})
+	if m, n := alphaDiff(plain, cleared); n > 0 {
+		t.Error("stroke after clearing the width profile differs from plain stroke", m, n)
+	}
+	if w := WidthStops()(0.5); w != 0 {
+		t.Error("empty stops should give no width", w)
+	}
+	if w := taper(2); w != 40 {
+		t.Error("width past the last stop", w)
+	}
+
+	// Curves are stroked with widths between the ends of the profile
+	circle := getCirclePath(256, 256, 100)
+	alpha := strokeAlpha(wx, wy, circle, func(sc Scanner) Adder {
+		st := NewStroker(wx, wy, sc)
+		st.SetStroke(10*64, 4*64, ButtCap, nil, nil, Round)
+		st.SetWidthProfile(taper)
+		return st
+	})
+	if m, n := alphaDiff(alpha, make([]uint8, wx*wy)); m != 255 || n < 2000 {
+		t.Error("tapered circle did not render", m, n)
+	}
+	if alpha[256*wx+256] != 0 {
+		t.Error("tapered circle should not be filled")
+	}
+}
+
+func TestStrokeAlignment(t *testing.T) {
+	const wx, wy = 300, 300
+	square := getSquarePath(100, 100, 200, 200)
+	coverage := func(alpha []uint8, in func(x, y int) bool) (sum float64) {
+		for y := 0; y < wy; y++ {
+			for x := 0; x < wx; x++ {
+				if in(x, y) {
+					sum += float64(alpha[y*wx+x]) / 255
+				}
+			}
+		}
+		return
+	}
+	inSquare := func(x, y int) bool { return x >= 100 && x < 200 && y >= 100 && y < 200 }
+	outSquare := func(x, y int) bool { return !inSquare(x, y) }
+	for _, tc := range []struct {
+		align    StrokeAlign
+		area     float64
+		wrongOut func(x, y int) bool
+	}{
+		{AlignCenter, 120*120 - 80*80, nil},
+		{AlignInside, 100*100 - 60*60, outSquare},
+		{AlignOutside, 140*140 - 100*100, inSquare},
+	} {
+		for _, jm := range []JoinMode{Arc, ArcClip, Miter, MiterClip, Bevel, Round} {
+			alpha := strokeAlpha(wx, wy, square, func(sc Scanner) Adder {
+				st := NewStroker(wx, wy, sc)
+				st.SetStroke(20*64, 4*64, ButtCap, nil, nil, jm)
+				st.SetAlignment(tc.align)
+				return st
+			})
+			area := coverage(alpha, func(x, y int) bool { return true })
+			if jm == Miter || jm == MiterClip || jm == Arc || jm == ArcClip || tc.align == AlignInside {
+				if math.Abs(area-tc.area) > 2 {
+					t.Error("wrong stroke area", tc.align, jm, area, tc.area)
+				}
+			} else if area > tc.area || area < tc.area-4*200 {
+				t.Error("wrong stroke area", tc.align, jm, area, tc.area)
+			}
+			if tc.wrongOut != nil {
+				if a := coverage(alpha, tc.wrongOut); a > 0.5 {
+					t.Error("stroke on the wrong side of the path", tc.align, jm, a)
+				}
+			}
+		}
+	}
+
+	// Dashes are clipped to the side as well, and open paths stay centered
+	alpha := strokeAlpha(wx, wy, square, func(sc Scanner) Adder {
+		d := NewDasher(wx, wy, sc)
+		d.SetStroke(20*64, 4*64, ButtCap, nil, nil, Miter, []float64{25, 25}, 0)
+		d.SetAlignment(AlignInside)
+		return d
+	})
+	if a := coverage(alpha, outSquare); a > 0.5 {
+		t.Error("dashes outside of the path", a)
+	}
+	if a := coverage(alpha, inSquare); a < 1000 || a > 6400-1000 {
+		t.Error("wrong dashed area", a)
+	}
+	// The clipped outline is drawn as captured
+	d := NewDasher(wx, wy, NewScannerGV(wx, wy, image.NewRGBA(image.Rect(0, 0, 1, 1)), image.Rect(0, 0, 1, 1)))
+	d.SetStroke(20*64, 4*64, ButtCap, nil, nil, Miter, []float64{25, 25}, 0)
+	d.SetAlignment(AlignInside)
+	filled := strokeAlpha(wx, wy, d.StrokeOutline(square), func(sc Scanner) Adder { return NewFiller(wx, wy, sc) })
+	if m, n := alphaDiff(alpha, filled); n > 0 {
+		t.Error("aligned dashes differ from their outline", m, n)
+	}
+	// SetStroke centers the stroke again
+	d.SetStroke(20*64, 4*64, ButtCap, nil, nil, Miter, nil, 0)
+	if a := d.Alignment(); a != AlignCenter {
+		t.Error("SetStroke should center the stroke", a)
+	}
+	var line Path
+	line.Start(ToFixedP(50, 50))
+	line.Line(ToFixedP(250, 50))
+	line.Stop(false)
+	mk := func(a StrokeAlign) func(sc Scanner) Adder {
+		return func(sc Scanner) Adder {
+			st := NewStroker(wx, wy, sc)
+			st.SetStroke(20*64, 4*64, ButtCap, nil, nil, Miter)
+			st.SetAlignment(a)
+			return st
+		}
+	}
+	if m, n := alphaDiff(strokeAlpha(wx, wy, line, mk(AlignOutside)), strokeAlpha(wx, wy, line, mk(AlignCenter))); n > 0 {
+		t.Error("open paths should be stroked centered", m, n)
+	}
+}
+
+func TestTransformStroker(t *testing.T) {
+	const wx, wy = 400, 300
+	circle := getCirclePath(0, 0, 50)
+	m := Identity.Translate(200, 150).Scale(3, 1)
+	// run returns the thickness of the stroke across the top and the right
+	// of the stretched circle
+	run := func(space StrokeSpace, dashed bool) (top, right float64) {
+		alpha := strokeAlpha(wx, wy, circle, func(sc Scanner) Adder {
+			if dashed {
+				d := NewDasher(wx, wy, sc)
+				d.SetStroke(4*64, 4*64, ButtCap, nil, nil, Round, []float64{1000, 1}, 0)
+				return NewTransformStroker(d, m, space)
+			}
+			st := NewStroker(wx, wy, sc)
+			st.SetStroke(4*64, 4*64, ButtCap, nil, nil, Round)
+			return NewTransformStroker(st, m, space)
+		})
+		for y := 0; y < 150; y++ {
+			top += float64(alpha[y*wx+200]) / 255
+		}
+		for x := 200; x < wx; x++ {
+			right += float64(alpha[150*wx+x]) / 255
+		}
+		return
+	}
+	for _, dashed := range []bool{false, true} {
+		if top, right := run(NonScalingStroke, dashed); math.Abs(top-4) > 0.5 || math.Abs(right-4) > 0.5 {
+			t.Error("non-scaling stroke should keep its width", dashed, top, right)
+		}
+		if top, right := run(UserSpaceStroke, dashed); math.Abs(top-4) > 0.5 || math.Abs(right-12) > 0.5 {
+			t.Error("user space stroke should scale with the path", dashed, top, right)
+		}
+	}
+
+	// A user space stroke of open subpaths draws the mapped outline
+	p := getOpenCubicPath()
+	m = Identity.Translate(20, 10).Scale(2, 1.5)
+	st := NewStroker(wx, wy, NewScannerGV(wx, wy, image.NewRGBA(image.Rect(0, 0, 1, 1)), image.Rect(0, 0, 1, 1)))
+	st.SetStroke(4*64, 4*64, ButtCap, nil, nil, Round)
+	st.SetToleranceFor(0.1, m)
+	outline := st.StrokeOutline(p).Transform(m)
+	drawn := strokeAlpha(wx, wy, p, func(sc Scanner) Adder {
+		st := NewStroker(wx, wy, sc)
+		st.SetStroke(4*64, 4*64, ButtCap, nil, nil, Round)
+		return NewTransformStroker(st, m, UserSpaceStroke)
+	})
+	filled := strokeAlpha(wx, wy, outline, func(sc Scanner) Adder { return NewFiller(wx, wy, sc) })
+	if m, n := alphaDiff(drawn, filled); n > 0 {
+		t.Error("user space stroke differs from its mapped outline", m, n)
+	}
+}
+
+func TestStrokeOverlapFree(t *testing.T) {
+	const wx, wy = 512, 512
+	p := GetTestPath()
+	// Opaque strokes render the same with and without overlaps, apart from
+	// the pixels that the edges of overlapping pieces cross
+	for i, c := range []struct {
+		p      Path
+		jm     JoinMode
+		dashes []float64
+	}{
+		{p, ArcClip, nil},
+		{p, ArcClip, []float64{40, 10}},
+		{p, Arc, []float64{20, 10}},
+		{getCirclePath(150, 150, 120), ArcClip, nil},
+		{getCirclePath(150, 150, 120), ArcClip, []float64{40, 10}},
+		{getOpenCubicPath(), Round, []float64{20, 10}},
+	} {
+		mk := func(overlapFree bool) []uint8 {
+			img := image.NewRGBA(image.Rect(0, 0, wx, wy))
+			sc := NewScannerGV(wx, wy, img, img.Bounds())
+			sc.SetColor(colornames.Black)
+			d := NewDasher(wx, wy, sc)
+			d.SetStroke(16*64, 4*64, RoundCap, nil, RoundGap, c.jm, c.dashes, 0)
+			d.SetOverlapFree(overlapFree)
+			c.p.AddTo(d)
+			d.Draw()
+			alpha := make([]uint8, wx*wy)
+			for i := range alpha {
+				alpha[i] = img.Pix[i*4+3]
+			}
+			return alpha
+		}
+		if m, n := edgeDiff(mk(true), mk(false)); n > 0 {
+			t.Error("overlap free stroke differs", i, m, n)
+		}
+	}
+
+	// A translucent stroke that crosses itself covers the crossing and the
+	// joins once, and nothing outside of the stroke
+	var cross Path
+	cross.Start(ToFixedP(50, 50))
+	cross.Line(ToFixedP(250, 250))
+	cross.Line(ToFixedP(250, 50))
+	cross.Line(ToFixedP(50, 250))
+	cross.Stop(false)
+	for _, jm := range []JoinMode{Round, Miter, Arc} {
+		img := image.NewRGBA(image.Rect(0, 0, 300, 300))
+		st := NewStroker(300, 300, NewScannerGV(300, 300, img, img.Bounds()))
+		st.SetColor(color.NRGBA{0, 0, 0, 128})
+		st.SetStroke(30*64, 4*64, ButtCap, nil, nil, jm)
+		st.SetOverlapFree(true)
+		cross.AddTo(st)
+		st.Draw()
+		for _, pt := range []image.Point{{150, 150}, {150, 140}, {250, 250}, {240, 240}, {245, 225}} {
+			if a := img.RGBAAt(pt.X, pt.Y).A; a != 128 {
+				t.Error("wrong alpha in overlap free stroke", jm, pt, a)
+			}
+		}
+		for _, pt := range []image.Point{{280, 20}, {150, 20}, {20, 150}, {20, 20}} {
+			if a := img.RGBAAt(pt.X, pt.Y).A; a != 0 {
+				t.Error("overlap free stroke drawn outside of the stroke", jm, pt, a)
+			}
+		}
+	}
+
+	// The plain outline fills differently by the even-odd rule, but the
+	// overlap free outline fills the same by either rule
+	s := NewStroker(wx, wy, NewScannerGV(wx, wy, image.NewRGBA(image.Rect(0, 0, 1, 1)), image.Rect(0, 0, 1, 1)))
+	s.SetStroke(16*64, 4*64, RoundCap, nil, RoundGap, ArcClip)
+	o := s.StrokeOutline(p)
+	if _, n := alphaDiff(fillAlpha(wx, wy, Boolean(UnionOp, o, nil, false)), fillAlpha(wx, wy, o)); n == 0 {
+		t.Error("plain outline should overlap itself")
+	}
+	s.SetOverlapFree(true)
+	o = s.StrokeOutline(p)
+	if m, n := alphaDiff(fillAlpha(wx, wy, Boolean(UnionOp, o, nil, false)), fillAlpha(wx, wy, o)); n > 0 {
+		t.Error("overlap free outline should fill the same by either rule", m, n)
+	}
+}
+
+func TestStrokeHairline(t *testing.T) {
+	const wx, wy = 200, 200
+	var line Path
+	line.Start(ToFixedP(20, 100.3))
+	line.Line(ToFixedP(180, 120.7))
+	line.Stop(false)
+	draw := func(width float64, hairline bool, clr color.Color) (sum float64) {
+		img := image.NewRGBA(image.Rect(0, 0, wx, wy))
+		sc := NewScannerGV(wx, wy, img, img.Bounds())
+		d := NewDasher(wx, wy, sc)
+		d.SetColor(clr)
+		d.SetHairline(hairline)
+		d.SetStroke(fixed.Int26_6(width*64), 4*64, ButtCap, nil, nil, Miter, nil, 0)
+		line.AddTo(d)
+		d.Draw()
+		for y := 0; y < wy; y++ {
+			sum += float64(img.Pix[(y*wx+100)*4+3]) / 255
+		}
+		return
+	}
+	// The coverage across a hairline is that of the requested width
+	for _, w := range []float64{0.1, 0.25, 0.5, 1} {
+		if s := draw(w, true, colornames.Black); math.Abs(s-w) > 0.03 {
+			t.Error("wrong hairline coverage", w, s)
+		}
+	}
+	if s := draw(0, true, colornames.Black); math.Abs(s-1) > 0.03 {
+		t.Error("zero width hairline should be one pixel", s)
+	}
+	if s := draw(0, false, colornames.Black); s != 0 {
+		t.Error("zero width stroke should not draw", s)
+	}
+	// A hairline is a one pixel line drawn with less opacity
+	if a, b := draw(0.25, true, colornames.Black), draw(1, false, color.NRGBA{0, 0, 0, 64}); math.Abs(a-b) > 0.02 {
+		t.Error("hairline should match a translucent pixel wide line", a, b)
+	}
+	if s := draw(3, true, colornames.Black); math.Abs(s-3) > 0.1 {
+		t.Error("wide strokes are not hairlines", s)
+	}
+	// The opacity is scaled when the color is set on the scanner, as a color
+	// or a ColorFunc
+	for _, clr := range []interface{}{colornames.Black, ColorFunc(func(x, y int) color.Color { return colornames.Black })} {
+		img := image.NewRGBA(image.Rect(0, 0, wx, wy))
+		sc := NewScannerGV(wx, wy, img, img.Bounds())
+		st := NewStroker(wx, wy, sc)
+		st.SetHairline(true)
+		st.SetStroke(16, 4*64, ButtCap, nil, nil, Miter)
+		sc.SetColor(clr)
+		line.AddTo(st)
+		st.Draw()
+		var sum float64
+		for y := 0; y < wy; y++ {
+			sum += float64(img.Pix[(y*wx+100)*4+3]) / 255
+		}
+		if math.Abs(sum-0.25) > 0.03 {
+			t.Error("wrong hairline coverage with the scanner color", sum)
+		}
+	}
+	// The opacity of the hairline is not left on a shared scanner
+	img := image.NewRGBA(image.Rect(0, 0, wx, wy))
+	sc := NewScannerGV(wx, wy, img, img.Bounds())
+	st := NewStroker(wx, wy, sc)
+	st.SetHairline(true)
+	st.SetStroke(16, 4*64, ButtCap, nil, nil, Miter)
+	st.SetColor(colornames.Black)
+	line.AddTo(st)
+	st.Draw()
+	f := NewFiller(wx, wy, sc)
+	f.Clear()
+	f.SetColor(colornames.Red)
+	AddRect(10, 10, 50, 50, 0, f)
+	f.Draw()
+	if c := img.RGBAAt(30, 30); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Error("fill after a hairline should be opaque", c)
+	}
+}
+
+func TestStrokeZeroLength(t *testing.T) {
+	const wx, wy = 100, 100
+	area := func(p Path, capF CapFunc, dashes []float64) (sum float64) {
+		alpha := strokeAlpha(wx, wy, p, func(sc Scanner) Adder {
+			d := NewDasher(wx, wy, sc)
+			d.SetStroke(10*64, 4*64, capF, nil, nil, Miter, dashes, 0)
+			return d
+		})
+		for _, a := range alpha {
+			sum += float64(a) / 255
+		}
+		return
+	}
+	var mz, ml, mlz, mq, line Path
+	mz.Start(ToFixedP(50, 50))
+	mz.Stop(true)
+	ml.Start(ToFixedP(50, 50))
+	ml.Line(ToFixedP(50, 50))
+	ml.Stop(false)
+	mlz.Start(ToFixedP(50, 50))
+	mlz.Line(ToFixedP(50, 50))
+	mlz.Stop(true)
+	mq.Start(ToFixedP(50, 50))
+	mq.QuadBezier(ToFixedP(50, 50), ToFixedP(50, 50))
+	mq.Stop(true)
+	line.Start(ToFixedP(10, 50))
+	line.Line(ToFixedP(90, 50))
+	line.Stop(false)
+	for _, tc := range []struct {
+		capF CapFunc
+		dot  float64
+	}{{RoundCap, math.Pi * 25}, {SquareCap, 100}, {ButtCap, 0}} {
+		for _, p := range []Path{mz, ml, mlz, mq} {
+			for _, dashes := range [][]float64{nil, {0, 20}} {
+				if a := area(p, tc.capF, dashes); math.Abs(a-tc.dot) > 1 {
+					t.Error("wrong zero length subpath area", p, dashes, a, tc.dot)
+				}
+			}
+		}
+		// Zero length dashes are dots, including one at the very end
+		if a := area(line, tc.capF, []float64{0, 20}); math.Abs(a-5*tc.dot) > 5 {
+			t.Error("wrong zero length dash area", a, 5*tc.dot)
+		}
+	}
+	// A lone move is not drawn
+	var m Path
+	m.Start(ToFixedP(50, 50))
+	if a := area(m, RoundCap, nil); a != 0 {
+		t.Error("lone move should not be drawn", a)
+	}
+}
+
+func TestStrokeCapShapes(t *testing.T) {
+	const wx, wy = 300, 200
+	var line Path
+	line.Start(ToFixedP(100, 100))
+	line.Line(ToFixedP(200, 100))
+	line.Stop(false)
+	stroke := func(p Path, capF CapFunc, gf GapFunc, jm JoinMode) []uint8 {
+		return strokeAlpha(wx, wy, p, func(sc Scanner) Adder {
+			st := NewStroker(wx, wy, sc)
+			st.SetStroke(20*64, 4*64, capF, nil, gf, jm)
+			return st
+		})
+	}
+	area := func(alpha []uint8) (sum float64) {
+		for _, a := range alpha {
+			sum += float64(a) / 255
+		}
+		return
+	}
+	butt := area(stroke(line, ButtCap, nil, Miter))
+	for _, tc := range []struct {
+		name string
+		capF CapFunc
+		end  float64 // area of each cap
+	}{
+		{"triangle", TriangleCap, 100},
+		{"inverted triangle", InvertedTriangleCap, 100},
+		{"arrow", ArrowCap(1, math.Atan(0.75)), 300},
+		{"narrow arrow", ArrowCap(1, 0.1), 200},
+	} {
+		if a := area(stroke(line, tc.capF, nil, Miter)) - butt; math.Abs(a-2*tc.end) > 2 {
+			t.Error("wrong cap area", tc.name, a, 2*tc.end)
+		}
+	}
+	var sq Path
+	sq.Start(ToFixedP(0, 1))
+	sq.Line(ToFixedP(1, 1))
+	sq.Line(ToFixedP(1, -1))
+	sq.Line(ToFixedP(0, -1))
+	if m, n := alphaDiff(stroke(line, CapFromPath(sq), nil, Miter), stroke(line, SquareCap, nil, Miter)); n > 0 {
+		t.Error("square template cap differs from SquareCap", m, n)
+	}
+	var arc Path
+	arc.Start(ToFixedP(0, 1))
+	arc.CubeBezier(ToFixedP(0.5523, 1), ToFixedP(1, 0.5523), ToFixedP(1, 0))
+	arc.CubeBezier(ToFixedP(1, -0.5523), ToFixedP(0.5523, -1), ToFixedP(0, -1))
+	if m, n := alphaDiff(stroke(line, CapFromPath(arc), nil, Miter), stroke(line, RoundCap, nil, Miter)); n > 4 {
+		t.Error("arc template cap differs from RoundCap", m, n)
+	}
+
+	// A straight template gives a bevel join, and a template on an empty
+	// path falls back to a flat gap
+	var bend Path
+	bend.Start(ToFixedP(50, 150))
+	bend.Line(ToFixedP(150, 50))
+	bend.Line(ToFixedP(250, 150))
+	bend.Stop(false)
+	var straight Path
+	straight.Start(ToFixedP(0, 1))
+	straight.Line(ToFixedP(0, -1))
+	bevel := stroke(bend, ButtCap, nil, Bevel)
+	for _, gf := range []GapFunc{GapFromPath(straight), GapFromPath(nil)} {
+		if m, n := alphaDiff(stroke(bend, ButtCap, gf, Bevel), bevel); n > 0 {
+			t.Error("straight template gap differs from bevel", m, n)
+		}
+	}
+	if a, b := area(stroke(bend, ButtCap, GapFromPath(arc), Bevel)), area(bevel); a <= b+10 {
+		t.Error("arc template gap should fill past the bevel", a, b)
+	}
+}
+
+func TestStrokePen(t *testing.T) {
+	const wx, wy = 300, 300
+	var cross Path // lines at 0, 90, 45 and -45 degrees
+	cross.Start(ToFixedP(20, 50))
+	cross.Line(ToFixedP(120, 50))
+	cross.Start(ToFixedP(200, 20))
+	cross.Line(ToFixedP(200, 120))
+	cross.Start(ToFixedP(20, 150))
+	cross.Line(ToFixedP(120, 250))
+	cross.Start(ToFixedP(180, 250))
+	cross.Line(ToFixedP(280, 150))
+	cross.Stop(false)
+	// thickness returns the widths of the strokes across the middles of
+	// the lines, measured along x or y
+	thickness := func(alpha []uint8) (w [4]float64) {
+		for y := 0; y < 100; y++ {
+			w[0] += float64(alpha[y*wx+70]) / 255
+		}
+		for x := 150; x < wx; x++ {
+			w[1] += float64(alpha[70*wx+x]) / 255
+		}
+		for x := 0; x < 150; x++ {
+			w[2] += float64(alpha[200*wx+x]) / 255
+		}
+		for x := 150; x < wx; x++ {
+			w[3] += float64(alpha[200*wx+x]) / 255
+		}
+		return
+	}
+	draw := func(p Path, pen Pen, capF CapFunc, dashes []float64) []uint8 {
+		return strokeAlpha(wx, wy, p, func(sc Scanner) Adder {
+			d := NewDasher(wx, wy, sc)
+			d.SetStroke(10*64, 4*64, capF, nil, RoundGap, Round, dashes, 0)
+			d.SetPen(pen)
+			return d
+		})
+	}
+	near := func(a, b [4]float64) bool {
+		for i := range a {
+			if math.Abs(a[i]-b[i]) > 0.3 {
+				return false
+			}
+		}
+		return true
+	}
+	// A round ellipse is the round pen
+	p := GetTestPath()
+	if m, n := alphaDiff(draw(p, Pen{Shape: EllipsePen, Ratio: 1}, RoundCap, nil), draw(p, Pen{}, RoundCap, nil)); n > 100 {
+		t.Error("circular ellipse pen differs from round pen", m, n)
+	}
+	s2 := math.Sqrt2
+	// An ellipse half as wide across its angle
+	if w := thickness(draw(cross, Pen{Shape: EllipsePen, Ratio: 0.5}, ButtCap, nil)); !near(w, [4]float64{5, 10, math.Sqrt(62.5) * s2, math.Sqrt(62.5) * s2}) {
+		t.Error("wrong ellipse pen widths", w)
+	}
+	// A nib at 45 degrees draws nothing along it
+	if w := thickness(draw(cross, Pen{Shape: NibPen, Angle: math.Pi / 4}, ButtCap, nil)); !near(w, [4]float64{5 * s2, 5 * s2, 0, 10 * s2}) {
+		t.Error("wrong nib pen widths", w)
+	}
+	// Dashes are measured along the path
+	var line Path
+	line.Start(ToFixedP(50, 50))
+	line.Line(ToFixedP(250, 50))
+	line.Stop(false)
+	var sum float64
+	for _, a := range draw(line, Pen{Shape: NibPen, Angle: math.Pi / 2}, ButtCap, []float64{20, 20}) {
+		sum += float64(a) / 255
+	}
+	if math.Abs(sum-100*10) > 5 {
+		t.Error("wrong dashed nib area", sum)
+	}
+	// Caps are drawn on the ends of the nib
+	var capped float64
+	for _, a := range draw(line, Pen{Shape: NibPen, Angle: math.Pi / 2}, SquareCap, nil) {
+		capped += float64(a) / 255
+	}
+	if math.Abs(capped-(200+10)*10) > 5 {
+		t.Error("wrong capped nib area", capped)
+	}
+	// Dashes of zero length are dots, facing along the path, as with the
+	// round pen
+	var short, diag Path
+	short.Start(ToFixedP(50, 100))
+	short.Line(ToFixedP(230, 100))
+	short.Stop(false)
+	diag.Start(ToFixedP(50, 50))
+	diag.Line(ToFixedP(250, 250))
+	diag.Stop(false)
+	for _, c := range []struct {
+		p    Path
+		capF CapFunc
+		dot  float64
+	}{{short, RoundCap, math.Pi * 25}, {diag, SquareCap, 100}} {
+		round := draw(c.p, Pen{}, c.capF, []float64{0, 20})
+		if m, n := alphaDiff(draw(c.p, Pen{Shape: EllipsePen, Ratio: 1}, c.capF, []float64{0, 20}), round); m > 64 {
+			t.Error("zero length dashes of an ellipse pen differ from the round pen", m, n)
+		}
+		var sum float64
+		for _, a := range round {
+			sum += float64(a) / 255
+		}
+		if n := math.Floor(c.p.Length()/20) + 1; math.Abs(sum-n*c.dot) > n {
+			t.Error("wrong dot area", sum, n*c.dot)
+		}
+	}
+	var dots float64
+	for _, a := range draw(short, Pen{Shape: NibPen, Angle: math.Pi / 2}, SquareCap, []float64{0, 20}) {
+		dots += float64(a) / 255
+	}
+	if math.Abs(dots-10*100) > 10 {
+		t.Error("wrong nib dot area", dots)
+	}
+}
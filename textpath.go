@@ -0,0 +1,200 @@
+// Text laid out along a path
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+)
+
+type (
+	// TextAnchor determines which part of the text is placed at the start
+	// offset of a TextPath
+	TextAnchor uint8
+
+	// TextPathMethod determines how glyphs follow a TextPath
+	TextPathMethod uint8
+
+	// TextPathSpacing determines how the spacing of glyphs on a TextPath is
+	// adjusted for the bends of the path
+	TextPathSpacing uint8
+)
+
+// TextAnchor constants, which correspond to the SVG text-anchor values
+const (
+	AnchorStart TextAnchor = iota
+	AnchorMiddle
+	AnchorEnd
+)
+
+// TextPathMethod constants, which correspond to the SVG textPath method values
+const (
+	// TextPathAlign rotates each glyph as a rigid shape to the direction of
+	// the path at the middle of the glyph
+	TextPathAlign TextPathMethod = iota
+	// TextPathStretch bends the outline of each glyph to follow the path, so
+	// that the glyphs curve with it and stay joined for connected scripts
+	TextPathStretch
+)
+
+// TextPathSpacing constants, which correspond to the SVG textPath spacing
+// values
+const (
+	// SpacingExact places the glyphs by the advance widths and kerning of the
+	// font as measured along the path
+	SpacingExact TextPathSpacing = iota
+	// SpacingAuto scales the advances by the bend of the path at the middle
+	// height of lower case letters, so that glyphs do not crowd on the inside
+	// of curves or spread on the outside
+	SpacingAuto
+)
+
+// TextPath lays out a string along a path, like the SVG textPath element.
+// The baseline of the text follows the path, measured by arc length, with the
+// glyphs above it on the left side of the direction of the path. Glyphs whose
+// middle falls before the start or after the end of the path are not drawn.
+// Where the path has several subpaths, the text continues from one to the
+// next without counting the gaps between them.
+type TextPath struct {
+	Face        *FontFace
+	Path        Path
+	StartOffset float64 // distance along the path in pixels of the anchor
+	Anchor      TextAnchor
+	Method      TextPathMethod
+	Spacing     TextPathSpacing
+	// Tolerance is the accuracy in pixels of the bent outlines of
+	// TextPathStretch; 0 or less uses the WarpAdder default.
+	Tolerance float64
+}
+
+// placedGlyph is a glyph positioned along the path. at is the distance along
+// the path of its origin, scale the factor applied to its advance and mid
+// the distance along the path of its middle.
+type placedGlyph struct {
+	g          sfnt.GlyphIndex
+	at, adv    float64
+	scale, mid float64
+}
+
+// textFrame returns the point and unit tangent of the path at distance l.
+// Beyond the ends the path is extended along the end tangents.
+func textFrame(m *PathMeasure, l float64) (p, t Point) {
+	c := math.Max(0, math.Min(m.Length(), l))
+	p, t = m.PointAtLength(c), m.TangentAtLength(c)
+	return p.Add(t.Mul(l - c)), t
+}
+
+// textBend returns the rate of turning of the path in radians per pixel over
+// the span of length w around l
+func textBend(m *PathMeasure, l, w float64) float64 {
+	if w <= 0 {
+		return 0
+	}
+	_, t0 := textFrame(m, l-w/2)
+	_, t1 := textFrame(m, l+w/2)
+	return math.Atan2(t0.Cross(t1), t0.Dot(t1)) / w
+}
+
+// midHeight returns the y of the middle of the lower case letters in text
+// space, which is negative since it is above the baseline
+func (tp *TextPath) midHeight() float64 {
+	f := tp.Face
+	met, err := f.Font.Metrics(&f.buf, f.unitsPPEM(), font.HintingNone)
+	if err != nil || met.XHeight <= 0 {
+		return -0.25 * f.Size
+	}
+	return -float64(met.XHeight) / 64 * f.scale() / 2
+}
+
+// place returns the glyphs of s positioned along the measured path
+func (tp *TextPath) place(m *PathMeasure, s string) ([]placedGlyph, error) {
+	var gs []placedGlyph
+	total, err := tp.Face.layout(s, func(g sfnt.GlyphIndex, x, adv float64) error {
+		gs = append(gs, placedGlyph{g: g, at: x, adv: adv, scale: 1})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	start := tp.StartOffset
+	switch tp.Anchor {
+	case AnchorMiddle:
+		start -= total / 2
+	case AnchorEnd:
+		start -= total
+	}
+	if tp.Spacing == SpacingAuto {
+		// At height y from the baseline, a path turning at rate k moves
+		// 1 - k*y times as fast as the baseline, so the advances on the
+		// baseline are divided by that to keep the glyphs evenly spaced at
+		// the middle of the letters. The anchor is placed by the unscaled
+		// advance of the text.
+		y := tp.midHeight()
+		pos, prevX := start, 0.0
+		for i := range gs {
+			if i > 0 {
+				d := gs[i].at - prevX
+				pos += d * gs[i-1].scale
+			}
+			prevX = gs[i].at
+			k := textBend(m, pos+gs[i].adv/2, gs[i].adv)
+			gs[i].scale = 1 / math.Max(0.25, math.Min(4, 1-k*y))
+			gs[i].at = pos
+		}
+	} else {
+		for i := range gs {
+			gs[i].at += start
+		}
+	}
+	for i := range gs {
+		gs[i].mid = gs[i].at + gs[i].adv*gs[i].scale/2
+	}
+	return gs, nil
+}
+
+// AddTo sends the outlines of the glyphs of s, placed along the path, to q.
+// q may be a Filler, Stroker or Dasher to render the text directly, or a
+// Path to keep the outlines.
+func (tp *TextPath) AddTo(q Adder, s string) error {
+	m := NewPathMeasure(tp.Path)
+	gs, err := tp.place(m, s)
+	if err != nil {
+		return err
+	}
+	l := m.Length()
+	if tp.Method == TextPathStretch {
+		w := &WarpAdder{Adder: q, Tolerance: tp.Tolerance, Warp: func(x, y float64) (float64, float64) {
+			p, t := textFrame(m, x)
+			return p.X - t.Y*y, p.Y + t.X*y
+		}}
+		for _, g := range gs {
+			if g.mid < 0 || g.mid > l {
+				continue
+			}
+			if _, err := tp.Face.AddGlyph(w, g.g, Identity.Translate(g.at, 0).Scale(g.scale, 1)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, g := range gs {
+		if g.mid < 0 || g.mid > l {
+			continue
+		}
+		p, t := textFrame(m, g.mid)
+		a := Identity.Translate(p.X, p.Y).Rotate(math.Atan2(t.Y, t.X)).Translate(-g.adv/2, 0)
+		if _, err := tp.Face.AddGlyph(q, g.g, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToPath returns the outlines of the glyphs of s placed along the path
+func (tp *TextPath) ToPath(s string) (p Path, err error) {
+	err = tp.AddTo(&p, s)
+	return
+}
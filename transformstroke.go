@@ -0,0 +1,125 @@
+// Stroking in one coordinate space and rendering in another
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"golang.org/x/image/math/fixed"
+)
+
+// transformStrokeTol is the flattening tolerance in pixels of user space
+// strokes when the stroker has none set
+const transformStrokeTol = 0.1
+
+type (
+	// StrokeSpace determines the space in which the width, dashes and
+	// miter limit of a TransformStroker are measured
+	StrokeSpace uint8
+
+	// StrokeRasterx is a Rasterx that can stroke, such as a Stroker or a
+	// Dasher
+	StrokeRasterx interface {
+		Rasterx
+		StrokeOutline(p Path) Path
+		SetTolerance(tol float64)
+		SetToleranceFor(tol float64, m Matrix2D)
+		Tolerance() float64
+		addOutline(o Path)
+	}
+
+	// TransformStroker is an Adder that maps a path from user space to
+	// device space by M and strokes it with a Stroker or Dasher in the
+	// space given by Space.
+	TransformStroker struct {
+		M     Matrix2D
+		Space StrokeSpace
+		r     StrokeRasterx
+		path  Path // the subpath being collected in user space
+	}
+)
+
+// StrokeSpace constants
+const (
+	// NonScalingStroke strokes in device space, after the transform, so
+	// the stroke keeps its width however the path is scaled, like
+	// vector-effect: non-scaling-stroke in SVG.
+	NonScalingStroke StrokeSpace = iota
+	// UserSpaceStroke strokes in user space and maps the outline of the
+	// stroke by M, so the pen is scaled, rotated and skewed with the path.
+	// A round pen becomes an ellipse under a non-uniform scale or skew.
+	UserSpaceStroke
+)
+
+// NewTransformStroker returns a TransformStroker that strokes with r, using
+// its stroke settings, after mapping by m
+func NewTransformStroker(r StrokeRasterx, m Matrix2D, space StrokeSpace) *TransformStroker {
+	return &TransformStroker{M: m, Space: space, r: r}
+}
+
+// Start starts a new path
+func (t *TransformStroker) Start(a fixed.Point26_6) {
+	if t.Space == NonScalingStroke {
+		t.r.Start(t.M.TFixed(a))
+		return
+	}
+	t.strokeUser(false)
+	t.path.Start(a)
+}
+
+// Line adds a linear segment to the current curve.
+func (t *TransformStroker) Line(b fixed.Point26_6) {
+	if t.Space == NonScalingStroke {
+		t.r.Line(t.M.TFixed(b))
+		return
+	}
+	t.path.Line(b)
+}
+
+// QuadBezier adds a quadratic segment to the current curve.
+func (t *TransformStroker) QuadBezier(b, c fixed.Point26_6) {
+	if t.Space == NonScalingStroke {
+		t.r.QuadBezier(t.M.TFixed(b), t.M.TFixed(c))
+		return
+	}
+	t.path.QuadBezier(b, c)
+}
+
+// CubeBezier adds a cubic segment to the current curve.
+func (t *TransformStroker) CubeBezier(b, c, d fixed.Point26_6) {
+	if t.Space == NonScalingStroke {
+		t.r.CubeBezier(t.M.TFixed(b), t.M.TFixed(c), t.M.TFixed(d))
+		return
+	}
+	t.path.CubeBezier(b, c, d)
+}
+
+// Stop ends the current curve, which is stroked, or closed and stroked if
+// closeLoop is true.
+func (t *TransformStroker) Stop(closeLoop bool) {
+	if t.Space == NonScalingStroke {
+		t.r.Stop(closeLoop)
+		return
+	}
+	t.strokeUser(closeLoop)
+}
+
+// strokeUser strokes the collected subpath in user space and fills the
+// mapped outline. Curves are flattened to the tolerance of the stroker, or
+// a default if it has none, as measured after the transform.
+func (t *TransformStroker) strokeUser(closeLoop bool) {
+	if len(t.path) == 0 {
+		return
+	}
+	t.path.Stop(closeLoop)
+	tol := t.r.Tolerance()
+	if tol <= 0 {
+		t.r.SetToleranceFor(transformStrokeTol, t.M)
+	} else {
+		t.r.SetToleranceFor(tol, t.M)
+	}
+	o := t.r.StrokeOutline(t.path)
+	t.r.SetTolerance(tol)
+	t.path.Clear()
+	o.TransformInPlace(t.M)
+	t.r.addOutline(o)
+}
@@ -0,0 +1,135 @@
+// Trimming of paths by fractions of their length
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import "math"
+
+// TrimMode determines how a trim is applied to a path with several subpaths
+type TrimMode uint8
+
+// TrimMode constants. These correspond to the simultaneous and individual
+// modes of the Lottie trim path.
+const (
+	// TrimSimultaneous trims each subpath by the same fractions of its own length
+	TrimSimultaneous TrimMode = iota
+	// TrimIndividual trims the subpaths as if they were joined end to end
+	TrimIndividual
+)
+
+// Trim returns the portion of each subpath from fraction start to fraction
+// end of its length. Curves are split at the exact parameters that correspond
+// to the trimmed lengths.
+func (p Path) Trim(start, end float64) Path {
+	return p.TrimOffset(start, end, 0, TrimSimultaneous)
+}
+
+// TrimOffset returns the portion of the path from fraction start to fraction
+// end of its length, with both shifted by the fraction offset. If the
+// shifted range passes the end of the path it wraps around to the start, and
+// for closed subpaths the two pieces are joined at the start point. If start
+// is greater than end the two are swapped. The mode determines if the
+// fractions apply to each subpath or to the path as a whole. The returned
+// subpaths are open unless a closed subpath is kept in its entirety, so the
+// result is ready for a Stroker or Dasher.
+func (p Path) TrimOffset(start, end, offset float64, mode TrimMode) (q Path) {
+	if start > end {
+		start, end = end, start
+	}
+	start, end = math.Max(start, 0), math.Min(end, 1)
+	span := end - start
+	if span <= 0 {
+		return
+	}
+	start = math.Mod(start+offset, 1)
+	if start < 0 {
+		start++
+	}
+	end = start + span
+
+	m := NewPathMeasure(p)
+	if mode == TrimSimultaneous || len(m.sps) == 1 {
+		for i, sp := range m.sps {
+			m.trimSubpath(i, start*m.spLens[i], end*m.spLens[i], sp.closed).addTo(&q)
+		}
+		return
+	}
+	var base float64
+	for i := range m.sps {
+		spLen := m.spLens[i]
+		for _, r := range [2][2]float64{{start, end}, {start - 1, end - 1}} {
+			l0, l1 := math.Max(r[0]*m.total-base, 0), math.Min(r[1]*m.total-base, spLen)
+			if l1 > l0 {
+				m.trimSubpath(i, l0, l1, false).addTo(&q)
+			}
+		}
+		base += spLen
+	}
+	return
+}
+
+// pieces is a list of subpaths resulting from a trim
+type pieces []subpath
+
+func (ps pieces) addTo(q Adder) {
+	for _, sp := range ps {
+		if len(sp.segs) > 0 {
+			sp.addTo(q)
+		}
+	}
+}
+
+// trimSubpath returns the part of subpath i from length l0 to l1. Lengths
+// greater than the subpath length wrap around to the start; if canJoin is true
+// the wrapped part continues the first piece, otherwise it is a separate piece.
+func (m *PathMeasure) trimSubpath(i int, l0, l1 float64, canJoin bool) pieces {
+	sp, spLen := m.sps[i], m.spLens[i]
+	if spLen <= 0 || l1 <= l0 {
+		return nil
+	}
+	if l1-l0 >= spLen && sp.closed && l0 == 0 {
+		return pieces{sp}
+	}
+	first := m.extract(i, l0, math.Min(l1, spLen))
+	if l1 <= spLen {
+		return pieces{first}
+	}
+	second := m.extract(i, 0, l1-spLen)
+	if !canJoin || len(first.segs) == 0 {
+		return pieces{first, second}
+	}
+	first.segs = append(first.segs, second.segs...)
+	return pieces{first}
+}
+
+// extract returns an open subpath that is the part of subpath i from length l0 to l1
+func (m *PathMeasure) extract(i int, l0, l1 float64) (out subpath) {
+	sp, lens := m.sps[i], m.segLens[i]
+	var pos float64
+	for j, s := range sp.segs {
+		sl := lens[j]
+		a, b := pos, pos+sl
+		pos = b
+		if b <= l0 || a >= l1 || sl == 0 {
+			continue
+		}
+		t0, t1 := 0.0, 1.0
+		if l0 > a {
+			t0 = s.paramAt(l0-a, sl)
+		}
+		if l1 < b {
+			t1 = s.paramAt(l1-a, sl)
+		}
+		if t1 <= t0 {
+			continue
+		}
+		piece := s.sub(t0, t1)
+		if len(out.segs) == 0 {
+			out.start = piece.P[0]
+		} else {
+			piece.P[0] = out.segs[len(out.segs)-1].end() // keep the pieces contiguous
+		}
+		out.segs = append(out.segs, piece)
+	}
+	return
+}
@@ -0,0 +1,213 @@
+// Non-affine transforms of paths
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	warpTol   = 0.1  // default WarpAdder tolerance in pixels
+	warpDepth = 16   // maximum depth of warp subdivision
+	warpH     = 1e-3 // step in pixels for the finite difference Jacobian
+)
+
+type (
+	// ProjectiveMatrix is a 3x3 matrix in row major order that maps (x, y)
+	// to ((m0*x + m1*y + m2)/w, (m3*x + m4*y + m5)/w), where
+	// w = m6*x + m7*y + m8. It can represent perspective, which Matrix2D
+	// cannot.
+	ProjectiveMatrix [9]float64
+
+	// WarpAdder is an Adder that applies the Warp function to all points.
+	// Lines and curves are replaced by cubic curves that follow the warped
+	// shape to within Tolerance pixels, subdividing where the warp bends them.
+	// If StraightLines is true, the warp is assumed to keep lines straight, as
+	// projective transforms do, and lines are not subdivided.
+	WarpAdder struct {
+		Adder
+		Warp          func(x, y float64) (float64, float64)
+		Tolerance     float64 // 0 or less uses a default of 0.1 pixels
+		StraightLines bool
+		first, cur    Point // start and current points before warping
+	}
+)
+
+// IdentityProjective is the identity projective matrix
+var IdentityProjective = ProjectiveMatrix{1, 0, 0, 0, 1, 0, 0, 0, 1}
+
+// ToProjective returns the projective matrix that performs the transform of a
+func (a Matrix2D) ToProjective() ProjectiveMatrix {
+	return ProjectiveMatrix{a.A, a.C, a.E, a.B, a.D, a.F, 0, 0, 1}
+}
+
+// Transform maps the point x1, y1 by the matrix
+func (m ProjectiveMatrix) Transform(x1, y1 float64) (x2, y2 float64) {
+	w := m[6]*x1 + m[7]*y1 + m[8]
+	return (m[0]*x1 + m[1]*y1 + m[2]) / w, (m[3]*x1 + m[4]*y1 + m[5]) / w
+}
+
+// Mult returns m*b, which applies b and then m
+func (m ProjectiveMatrix) Mult(b ProjectiveMatrix) (c ProjectiveMatrix) {
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				c[i*3+j] += m[i*3+k] * b[k*3+j]
+			}
+		}
+	}
+	return
+}
+
+// det returns the determinant of the matrix
+func (m ProjectiveMatrix) det() float64 {
+	return m[0]*(m[4]*m[8]-m[5]*m[7]) - m[1]*(m[3]*m[8]-m[5]*m[6]) + m[2]*(m[3]*m[7]-m[4]*m[6])
+}
+
+// Invert returns the inverse matrix
+func (m ProjectiveMatrix) Invert() ProjectiveMatrix {
+	n := matrix3(m)
+	return ProjectiveMatrix(*n.Invert())
+}
+
+// squareToQuad returns the projective matrix that maps the corners of the
+// unit square, (0,0), (1,0), (1,1) and (0,1), to the points q, following
+// Heckbert, "Fundamentals of Texture Mapping and Image Warping", 1989.
+func squareToQuad(q [4]Point) (ProjectiveMatrix, bool) {
+	sx := q[0].X - q[1].X + q[2].X - q[3].X
+	sy := q[0].Y - q[1].Y + q[2].Y - q[3].Y
+	if sx == 0 && sy == 0 { // affine
+		return ProjectiveMatrix{q[1].X - q[0].X, q[3].X - q[0].X, q[0].X,
+			q[1].Y - q[0].Y, q[3].Y - q[0].Y, q[0].Y, 0, 0, 1}, true
+	}
+	dx1, dx2 := q[1].X-q[2].X, q[3].X-q[2].X
+	dy1, dy2 := q[1].Y-q[2].Y, q[3].Y-q[2].Y
+	det := dx1*dy2 - dx2*dy1
+	if det == 0 {
+		return ProjectiveMatrix{}, false
+	}
+	g := (sx*dy2 - dx2*sy) / det
+	h := (dx1*sy - sx*dy1) / det
+	return ProjectiveMatrix{
+		q[1].X - q[0].X + g*q[1].X, q[3].X - q[0].X + h*q[3].X, q[0].X,
+		q[1].Y - q[0].Y + g*q[1].Y, q[3].Y - q[0].Y + h*q[3].Y, q[0].Y,
+		g, h, 1}, true
+}
+
+// QuadToQuad returns the projective matrix that maps the corners src to the
+// corners dst, in the same order, such as to place a flat design onto a
+// surface seen in perspective. It returns false if three of the corners of
+// either quadrilateral are on a line.
+func QuadToQuad(src, dst [4]Point) (ProjectiveMatrix, bool) {
+	ms, ok := squareToQuad(src)
+	if !ok {
+		return ProjectiveMatrix{}, false
+	}
+	md, ok := squareToQuad(dst)
+	if !ok {
+		return ProjectiveMatrix{}, false
+	}
+	if ms.det() == 0 || md.det() == 0 {
+		return ProjectiveMatrix{}, false
+	}
+	return md.Mult(ms.Invert()), true
+}
+
+// NewProjectiveAdder returns a WarpAdder that maps all points by m. Since
+// projective transforms keep lines straight, lines stay lines, and only
+// curves are subdivided. Paths should not cross the line where the
+// denominator of m is zero, which is the horizon of a perspective view.
+func NewProjectiveAdder(a Adder, m ProjectiveMatrix) *WarpAdder {
+	return &WarpAdder{Adder: a, Warp: m.Transform, StraightLines: true}
+}
+
+// warp returns the warped point p
+func (w *WarpAdder) warp(p Point) Point {
+	x, y := w.Warp(p.X, p.Y)
+	return Point{x, y}
+}
+
+// warpDeriv returns the derivative of the warped point p moving with velocity
+// v, using a central difference of the warp
+func (w *WarpAdder) warpDeriv(p, v Point) Point {
+	l := v.Len()
+	if l == 0 {
+		return Point{}
+	}
+	step := v.Mul(warpH / l)
+	return w.warp(p.Add(step)).Sub(w.warp(p.Sub(step))).Mul(l / (2 * warpH))
+}
+
+// addWarped sends the warped part of the segment s between t0 and t1 to the
+// wrapped Adder as cubic curves. Each piece is the Hermite cubic that matches
+// the warped end points and derivatives; it is split if it strays from the
+// warped segment by more than the tolerance at the quarter points.
+func (w *WarpAdder) addWarped(s segment, t0, t1 float64, p0, p1 Point, depth int) {
+	tol := w.Tolerance
+	if tol <= 0 {
+		tol = warpTol
+	}
+	dt := t1 - t0
+	d0 := w.warpDeriv(s.eval(t0), s.deriv(t0)).Mul(dt / 3)
+	d1 := w.warpDeriv(s.eval(t1), s.deriv(t1)).Mul(dt / 3)
+	c := CubicBez{p0, p0.Add(d0), p1.Sub(d1), p1}
+	if depth < warpDepth {
+		for _, u := range [3]float64{0.25, 0.5, 0.75} {
+			if w.warp(s.eval(t0+u*dt)).Sub(c.Eval(u)).Len() > tol {
+				tm := t0 + dt/2
+				pm := w.warp(s.eval(tm))
+				w.addWarped(s, t0, tm, p0, pm, depth+1)
+				w.addWarped(s, tm, t1, pm, p1, depth+1)
+				return
+			}
+		}
+	}
+	w.Adder.CubeBezier(c.P1.Fixed(), c.P2.Fixed(), c.P3.Fixed())
+}
+
+// add sends the warped segment s to the wrapped Adder
+func (w *WarpAdder) add(s segment) {
+	w.cur = s.end()
+	if s.degenerate() {
+		w.Adder.Line(w.warp(s.end()).Fixed())
+		return
+	}
+	w.addWarped(s, 0, 1, w.warp(s.P[0]), w.warp(s.end()), 0)
+}
+
+// Start starts a new path
+func (w *WarpAdder) Start(a fixed.Point26_6) {
+	w.cur = ToPoint(a)
+	w.first = w.cur
+	w.Adder.Start(w.warp(w.cur).Fixed())
+}
+
+// Stop ends the current curve. The line that closes a closed curve is
+// warped like any other line.
+func (w *WarpAdder) Stop(closeLoop bool) {
+	if closeLoop && !w.StraightLines && w.cur != w.first {
+		w.add(segment{P: [4]Point{w.cur, w.first}, deg: 1})
+	}
+	w.Adder.Stop(closeLoop)
+}
+
+// Line adds a linear segment to the current curve.
+func (w *WarpAdder) Line(b fixed.Point26_6) {
+	if w.StraightLines {
+		w.cur = ToPoint(b)
+		w.Adder.Line(w.warp(w.cur).Fixed())
+		return
+	}
+	w.add(segment{P: [4]Point{w.cur, ToPoint(b)}, deg: 1})
+}
+
+// QuadBezier adds a quadratic segment to the current curve.
+func (w *WarpAdder) QuadBezier(b, c fixed.Point26_6) {
+	w.add(segment{P: [4]Point{w.cur, ToPoint(b), ToPoint(c)}, deg: 2})
+}
+
+// CubeBezier adds a cubic segment to the current curve.
+func (w *WarpAdder) CubeBezier(b, c, d fixed.Point26_6) {
+	w.add(segment{P: [4]Point{w.cur, ToPoint(b), ToPoint(c), ToPoint(d)}, deg: 3})
+}
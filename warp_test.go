@@ -0,0 +1,80 @@
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+// Created 2018 by S.R.Wiley
+package rasterx_test
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/srwiley/rasterx"
+)
+
+// warpError returns the largest distance from warped points of the segments
+// of src to the path got
+func warpError(src, got Path, warp func(x, y float64) (float64, float64)) (max float64) {
+	for _, s := range src.Segments() {
+		for i := 0; i <= 50; i++ {
+			p := s.Eval(float64(i) / 50)
+			x, y := warp(p.X, p.Y)
+			n, _ := got.NearestPoint(Point{x, y})
+			max = math.Max(max, n.Dist)
+		}
+	}
+	return
+}
+
+func TestWarp(t *testing.T) {
+	src := [4]Point{{0, 0}, {100, 0}, {100, 100}, {0, 100}}
+	dst := [4]Point{{30, 10}, {170, 20}, {190, 180}, {10, 150}}
+	m, ok := QuadToQuad(src, dst)
+	if !ok {
+		t.Fatal("QuadToQuad failed")
+	}
+	for i, p := range src {
+		if x, y := m.Transform(p.X, p.Y); (Point{x, y}).Sub(dst[i]).Len() > 1e-9 {
+			t.Error("corner not mapped", i, x, y)
+		}
+	}
+	if x, y := m.Invert().Mult(m).Transform(37, 58); math.Abs(x-37) > 1e-9 || math.Abs(y-58) > 1e-9 {
+		t.Error("inverse does not undo the matrix", x, y)
+	}
+	if _, ok := QuadToQuad(src, [4]Point{{0, 0}, {50, 50}, {100, 100}, {0, 100}}); ok {
+		t.Error("degenerate quadrilateral should fail")
+	}
+
+	// Lines stay lines under perspective, and curves follow the warp
+	var sq, out Path
+	AddRect(0, 0, 100, 100, 0, &sq)
+	sq.AddTo(NewProjectiveAdder(&out, m))
+	if out.String() != "M30.000,10.000 L170.000,20.000 L190.000,180.000 L10.000,150.000 Z" {
+		t.Error("wrong projected square", out)
+	}
+	circle := getCirclePath(50, 50, 40)
+	out = out[:0]
+	circle.AddTo(NewProjectiveAdder(&out, m))
+	if e := warpError(circle, out, m.Transform); e > 0.1+0.02 {
+		t.Error("projected circle exceeds tolerance", e)
+	}
+
+	// A nonlinear bend, where lines become curves
+	bend := func(x, y float64) (float64, float64) {
+		return x, y + 20*math.Sin(x/30)
+	}
+	for _, tol := range []float64{0.5, 0.05} {
+		out = out[:0]
+		w := &WarpAdder{Adder: &out, Warp: bend, Tolerance: tol}
+		sq.AddTo(w)
+		w.Stop(false)
+		if e := warpError(sq, out, bend); e > tol+0.02 {
+			t.Error("bent square exceeds tolerance", tol, e)
+		}
+		out = out[:0]
+		circle.AddTo(w)
+		if e := warpError(circle, out, bend); e > tol+0.02 {
+			t.Error("bent circle exceeds tolerance", tol, e)
+		}
+	}
+	if p := IdentityProjective.Mult(Identity.Translate(5, 6).ToProjective()); p != Identity.Translate(5, 6).ToProjective() {
+		t.Error("identity product wrong", p)
+	}
+}
@@ -0,0 +1,171 @@
+// Variable width strokes
+// Copyright 2018 by the rasterx Authors. All rights reserved.
+
+package rasterx
+
+import (
+	"math"
+	"sort"
+
+	"golang.org/x/image/math/fixed"
+)
+
+type (
+	// WidthFunc returns the width of a stroke in pixels at the fraction frac,
+	// from 0 to 1, of the length of the subpath being stroked
+	WidthFunc func(frac float64) float64
+
+	// WidthStop is the width of a stroke in pixels at the fraction Frac of
+	// the length of a subpath
+	WidthStop struct {
+		Frac, Width float64
+	}
+
+	// widthState holds the progress of a stroke whose subpaths are collected
+	// in buf and then replayed to the stroker, as described on buffering.
+	widthState struct {
+		fn        WidthFunc
+		buf       Path
+		replay    bool
+		wide      bool    // the stroke is drawn at twice the width for alignment
+		length    float64 // length in pixels of the subpath being stroked
+		at        float64 // length in pixels stroked so far
+		segEnd    float64 // length in pixels at the end of the current segment
+		miterRate fixed.Int26_6
+	}
+)
+
+// WidthStops returns a WidthFunc that interpolates linearly between the
+// stops, which are sorted by Frac. The width is that of the first or last
+// stop before the first or after the last.
+func WidthStops(stops ...WidthStop) WidthFunc {
+	ss := append([]WidthStop(nil), stops...)
+	sort.SliceStable(ss, func(i, j int) bool { return ss[i].Frac < ss[j].Frac })
+	return func(frac float64) float64 {
+		if len(ss) == 0 {
+			return 0
+		}
+		i := sort.Search(len(ss), func(i int) bool { return ss[i].Frac > frac })
+		switch {
+		case i == 0:
+			return ss[0].Width
+		case i == len(ss):
+			return ss[len(ss)-1].Width
+		}
+		a, b := ss[i-1], ss[i]
+		if b.Frac == a.Frac {
+			return b.Width
+		}
+		return a.Width + (b.Width-a.Width)*(frac-a.Frac)/(b.Frac-a.Frac)
+	}
+}
+
+// SetWidthProfile makes the width of the stroke follow fn along the length
+// of each subpath, in place of the width given to SetStroke, which still sets
+// the miter limit as a multiple of the local width. Joins and caps use the
+// width where they are drawn. The length of a subpath must be known before
+// it is stroked, so each subpath is held until Stop, with nothing drawn
+// before then; stop every subpath before calling Draw. A nil fn returns
+// to a constant width.
+func (r *Stroker) SetWidthProfile(fn WidthFunc) {
+	r.w.fn = fn
+	r.w.buf.Clear()
+	r.applyHairline() // restore the width set by SetStroke
+}
+
+// buffering reports if the stroker collects each subpath before stroking it.
+// A width profile needs the length of the whole subpath, an aligned stroke
+// clips to the whole subpath, and a shaped pen is swept along it, so each
+// of SetWidthProfile, SetAlignment and SetPen turns buffering on. A buffered
+// subpath is stroked by strokeBuffered when it is stopped, or when the next
+// subpath is started. Unlike plain stroking, which sends each segment to the
+// scanner as it is added, nothing is drawn for an open subpath until then,
+// so Stop must be called before Draw.
+func (r *Stroker) buffering() bool {
+	return (r.w.fn != nil || r.align != AlignCenter || r.pen.Shape != RoundPen) && !r.w.replay
+}
+
+// bufferStart begins collecting a new subpath to be stroked. An
+// unstopped subpath already collected is stroked as if it were stopped.
+func (r *Stroker) bufferStart(q Adder, a fixed.Point26_6) {
+	if len(r.w.buf) > 0 {
+		r.strokeBuffered(q, false)
+	}
+	r.w.buf.Start(a)
+}
+
+// strokeBuffered measures the collected subpath and replays it to q, which
+// is the Stroker or the Dasher that embeds it
+func (r *Stroker) strokeBuffered(q Adder, isClosed bool) {
+	if len(r.w.buf) == 0 {
+		return
+	}
+	r.w.buf.Stop(isClosed)
+	r.w.length = r.w.buf.Length()
+	r.w.replay = true
+	switch {
+	case r.pen.Shape != RoundPen:
+		r.strokePen(q)
+	case isClosed && r.align != AlignCenter:
+		r.strokeAligned(q)
+	default:
+		r.w.buf.AddTo(q)
+	}
+	r.w.replay = false
+	r.w.buf.Clear()
+}
+
+// halfWidthAt returns the half-width of the stroke at length l along the
+// subpath, or the half-width set by SetStroke if there is no width profile
+func (r *Stroker) halfWidthAt(l float64) fixed.Int26_6 {
+	if r.w.fn == nil {
+		return r.u
+	}
+	var frac float64
+	if r.w.length > 0 {
+		frac = math.Max(0, math.Min(1, l/r.w.length))
+	}
+	u := fixed.Int26_6(math.Max(0, r.w.fn(frac)*32))
+	if r.w.wide {
+		u *= 2
+	}
+	return u
+}
+
+// setWidthAt sets the half-width and miter limit of the stroke to those at
+// length l along the subpath. It does nothing if there is no width profile.
+func (r *Stroker) setWidthAt(l float64) {
+	if r.w.fn == nil {
+		return
+	}
+	r.u = r.halfWidthAt(l)
+	r.mLimit = (r.u * r.w.miterRate) >> 6
+}
+
+// beginSegment sets the stroke width for the join at the start of the line
+// or curve with the points pts, and records the length where it ends
+func (r *Stroker) beginSegment(pts ...fixed.Point26_6) {
+	if r.w.fn == nil {
+		return
+	}
+	s := segment{deg: len(pts) - 1}
+	for i, p := range pts {
+		s.P[i] = ToPoint(p)
+	}
+	r.setWidthAt(r.w.at)
+	r.w.segEnd = r.w.at + s.length()
+}
+
+// walkTo advances the stroked length by the line from a to b, which is part
+// of the current segment, and sets the stroke width to that at b
+func (r *Stroker) walkTo(a, b fixed.Point26_6) {
+	if r.w.fn == nil {
+		return
+	}
+	if b == r.leadPoint.P {
+		r.w.at = r.w.segEnd
+	} else {
+		r.w.at = math.Min(r.w.segEnd, r.w.at+ToPoint(b.Sub(a)).Len())
+	}
+	r.setWidthAt(r.w.at)
+}